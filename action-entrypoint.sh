@@ -1,5 +1,22 @@
 #!/bin/bash -e
 
+# Run the action directly on the runner if docker isn't used.
+if [ "$USE_DOCKER" = "false" ]; then
+    if ! command -v go >/dev/null; then
+        echo "::error::The Go toolchain is required to build the action when use-docker is false"
+        exit 1
+    fi
+
+    binary="$RUNNER_TEMP/sonar-scanner-action$(go env GOEXE)"
+
+    echo "::group::Building sonar-scanner-action"
+    CGO_ENABLED=0 go build -o "$binary" .
+    echo "::endgroup::"
+
+    cd "$SOURCES_LOCATION"
+    exec "$binary"
+fi
+
 image_name="sonar-scanner-$(uuidgen)"
 trap "docker image rm $image_name || true" EXIT
 
@@ -7,19 +24,69 @@ echo "::group::Building docker image"
 docker build --build-arg BASE_IMAGE=$IMAGE -t $image_name .
 echo "::endgroup::"
 
+# Make the event payload, job summary and outputs files available within the
+# container.
+github_args=(
+    -e GITHUB_ACTIONS
+    -e GITHUB_EVENT_NAME
+    -e GITHUB_REF
+    -e GITHUB_HEAD_REF
+)
+if [ -n "$GITHUB_EVENT_PATH" ]; then
+    github_args+=(
+        -e GITHUB_EVENT_PATH=/github/event.json
+        -v "$GITHUB_EVENT_PATH:/github/event.json:ro"
+    )
+fi
+if [ -n "$GITHUB_STEP_SUMMARY" ]; then
+    github_args+=(
+        -e GITHUB_STEP_SUMMARY=/github/step-summary.md
+        -v "$GITHUB_STEP_SUMMARY:/github/step-summary.md"
+    )
+fi
+if [ -n "$GITHUB_OUTPUT" ]; then
+    github_args+=(
+        -e GITHUB_OUTPUT=/github/output
+        -v "$GITHUB_OUTPUT:/github/output"
+    )
+fi
+
 echo "::group::Running sonar-scanner"
 docker run \
     --rm \
     -t \
     -e SONAR_HOST_URL \
     -e SONAR_HOST_CERT \
+    -e SONAR_CLIENT_CERT \
+    -e SONAR_CLIENT_KEY \
+    -e SONAR_CLIENT_KEY_PASSWORD \
     -e PROJECT_FILE_LOCATION \
+    -e MODE \
+    -e REPORT_TASK_FILE \
+    -e CE_TASK_ID \
     -e WAIT_FOR_QUALITY_GATE \
     -e QUALITY_GATE_WAIT_TIMEOUT \
+    -e FAIL_ON \
+    -e POLL_STRATEGY \
+    -e POLL_INTERVAL \
+    -e POLL_MAX_INTERVAL \
+    -e POLL_JITTER \
+    -e REQUEST_MAX_ATTEMPTS \
+    -e REQUEST_RETRY_INTERVAL \
     -e LOG_LEVEL \
     -e TLS_SKIP_VERIFY \
     -e SONAR_LOGIN \
     -e SONAR_PASSWORD \
+    -e DETECT_BRANCH \
+    -e SCANNER_ARGS \
+    -e SONAR_HTTP_PROXY \
+    -e HTTP_PROXY \
+    -e HTTPS_PROXY \
+    -e NO_PROXY \
+    -e http_proxy \
+    -e https_proxy \
+    -e no_proxy \
+    "${github_args[@]}" \
     -w "$SOURCES_MOUNT_POINT" \
     -v "$SOURCES_LOCATION:$SOURCES_MOUNT_POINT" \
     $image_name
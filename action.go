@@ -1,9 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/LowCostCustoms/sonar-scanner-action/internal/environment"
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/github"
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/masking"
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarapi"
 	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarscanner"
 	"github.com/sirupsen/logrus"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
@@ -17,66 +30,460 @@ func init() {
 }
 
 func main() {
-	// Get the process environment.
-	env, err := environment.Get()
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command given by the command line arguments and returns its
+// exit code, one of the sonarscanner ExitCode constants.
+func run(args []string) int {
+	name, args := splitCommand(args)
+
+	// Get the process environment overridden by the command line flags.
+	flags := flag.NewFlagSet(binaryName, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		printUsage(flags, name)
+	}
+	env, err := environment.Parse(flags, args)
+	if err == flag.ErrHelp {
+		return sonarscanner.ExitCodeSuccess
+	}
+	if err != nil {
+		log.Errorf("Failed to parse the process environment: %+v", err)
+		return sonarscanner.ExitCodeConfigError
+	}
+
+	if flags.NArg() != 0 {
+		log.Errorf("Unexpected arguments: %s", strings.Join(flags.Args(), " "))
+		return sonarscanner.ExitCodeConfigError
+	}
+
+	command, err := getCommand(env, name)
 	if err != nil {
-		log.Fatalf("Failed to parse the process environment: %+v", err)
+		log.Errorf("Invalid command: %s", err)
+		return sonarscanner.ExitCodeConfigError
+	}
+
+	masker := masking.NewMasker()
+	log.Formatter = &masking.Formatter{
+		Formatter: log.Formatter,
+		Masker:    masker,
 	}
+	maskSecrets(env, masker, env.Secrets()...)
 
 	log.Level = env.LogLevel
 	log.Infof("Log level set to %s", env.LogLevel)
 
+	if env.GithubActions {
+		log.AddHook(&github.AnnotationHook{
+			Writer: os.Stdout,
+			Prefix: sonarscanner.ScannerCliLogPrefix,
+			Masker: masker,
+		})
+	}
+
 	if env.TlsSkipVerify {
 		log.Warn("Sonar host certificate verification was disabled")
 	}
 
+	failurePolicy, err := sonarscanner.ParseFailurePolicy(env.FailOn)
+	if err != nil {
+		log.Errorf("Invalid fail-on policy: %s", err)
+		return sonarscanner.ExitCodeConfigError
+	}
+
+	pollStrategy, err := sonarscanner.NewPollStrategy(
+		env.PollStrategy,
+		env.PollInterval,
+		env.PollMaxInterval,
+		env.PollJitter,
+	)
+	if err != nil {
+		log.Errorf("Invalid poll strategy: %s", err)
+		return sonarscanner.ExitCodeConfigError
+	}
+
+	retryPolicy, err := sonarscanner.NewRetryPolicy(env.RequestMaxAttempts, env.RequestRetryInterval)
+	if err != nil {
+		log.Errorf("Invalid request retry policy: %s", err)
+		return sonarscanner.ExitCodeConfigError
+	}
+
+	branchParameters, err := getBranchParameters(env)
+	if err != nil {
+		log.Errorf("Failed to infer the branch parameters: %s", err)
+		return sonarscanner.ExitCodeConfigError
+	}
+
 	// Create a new sonar-scanner run.
 	runFactory := &sonarscanner.RunFactory{
-		SonarHostUrl:         env.SonarHostUrl,
-		SonarHostCert:        env.SonarHostCert,
-		TlsSkipVerify:        env.TlsSkipVerify,
-		ProjectFileLocation:  env.ProjectFileLocation,
-		SonarLogin:           env.SonarLogin,
-		SonarPassword:        env.SonarPassword,
-		ScannerVerboseOutput: env.LogLevel == logrus.DebugLevel,
-		LogEntry:             log.WithField("prefix", "sonar-scanner"),
-	}
-	run, err := runFactory.NewRun()
+		SonarHostUrl:           env.SonarHostUrl,
+		SonarHostCert:          env.SonarHostCert,
+		SonarClientCert:        env.SonarClientCert,
+		SonarClientKey:         env.SonarClientKey,
+		SonarClientKeyPassword: env.SonarClientKeyPassword,
+		TlsSkipVerify:          env.TlsSkipVerify,
+		ProjectFileLocation:    env.ProjectFileLocation,
+		SonarLogin:             env.SonarLogin,
+		SonarPassword:          env.SonarPassword,
+		ScannerVerboseOutput:   env.LogLevel == logrus.DebugLevel,
+		BranchParameters:       branchParameters,
+		ExtraArgs:              env.ScannerArgs,
+		ScannerPath:            env.ScannerPath,
+		ScannerWorkingDir:      env.ScannerWorkingDir,
+		HttpProxy:              env.SonarHttpProxy,
+		ProxyListenAddr:        env.ProxyListenAddr,
+		ProxyToken:             env.ProxyToken,
+		PollStrategy:           pollStrategy,
+		RetryPolicy:            retryPolicy,
+		ReportTaskFile:         env.ReportTaskFile,
+		CeTaskId:               env.CeTaskId,
+		LogEntry:               log.WithField("prefix", "sonar-scanner"),
+	}
+	scannerRun, err := runFactory.NewRun()
 	if err != nil {
-		log.Fatalf("Failed to create a sonar scanner run: %s", err)
+		log.Errorf("Failed to create a sonar scanner run: %s", err)
+		return sonarscanner.ExitCodeConfigError
 	}
 
-	// Run sonar-scanner.
-	log.Info("Running the sonar scanner cli ...")
-	err = run.RunScanner(context.Background())
-	if err != nil {
-		log.Fatalf("Failed to run sonar scanner: %s", err)
+	maskSecrets(env, masker, scannerRun.Secrets()...)
+
+	switch command {
+	case commandValidateConfig:
+		log.Info("Configuration is valid")
+		return sonarscanner.ExitCodeSuccess
+	case commandProxy:
+		return serveProxy(scannerRun)
+	default:
+		return analyze(env, command, scannerRun, failurePolicy)
 	}
+}
 
-	// Wait for the analysis task result if needed.
-	if env.WaitForQualityGate {
+// analyze runs sonar-scanner, unless the command only checks an existing
+// analysis, and checks the analysis results.
+func analyze(
+	env *environment.Environment,
+	command string,
+	scannerRun *sonarscanner.Run,
+	failurePolicy sonarscanner.FailurePolicy,
+) int {
+	var err error
+	summary := &github.StepSummary{}
+	var reportTask *sonarscanner.ReportTask
+	if command != commandScan {
+		// The report task is known upfront, so it's never read from a file.
+		reportTask, err = scannerRun.ReportTask()
+		if err != nil {
+			log.Errorf("Failed to get the analysis report task: %s", err)
+			return sonarscanner.ExitCodeConfigError
+		}
+
+		log.Infof("Checking the results of the analysis task %s", reportTask.CeTaskId)
+	} else {
+		// Run sonar-scanner.
+		log.Info("Running the sonar scanner cli ...")
+		scannerStartedAt := time.Now()
+		reportTask, err = scannerRun.RunScanner(context.Background())
+		summary.ScannerDuration = time.Since(scannerStartedAt)
+		if err != nil {
+			log.Errorf("Failed to run sonar scanner: %s", err)
+			summary.Error = err.Error()
+			writeStepSummary(env, summary)
+			return sonarscanner.ExitCode(err)
+		}
+
+		log.Infof("Analysis report submitted as the task %s", reportTask.CeTaskId)
+	}
+
+	if reportTask.DashboardUrl != "" {
+		log.Infof("Project dashboard is available at %s", reportTask.DashboardUrl)
+	}
+
+	summary.ReportTask = reportTask
+	writeOutputs(env, reportTask)
+
+	// Wait for the analysis task result if needed, which is always the case
+	// when an existing analysis is checked.
+	if env.WaitForQualityGate || command != commandScan {
 		log.Info("Retrieving the project analysis status ...")
 
 		ctx, cancel := context.WithTimeout(context.Background(), env.QualityGateWaitTimeout)
 		defer cancel()
 
-		status, err := run.RetrieveProjectanalysisStatus(ctx)
+		retrieveStatus := scannerRun.RetrieveProjectanalysisStatus
+		if command == commandGate {
+			retrieveStatus = scannerRun.CheckProjectAnalysisStatus
+		}
+
+		waitStartedAt := time.Now()
+		status, err := retrieveStatus(ctx)
+		summary.WaitDuration = time.Since(waitStartedAt)
 		if err != nil {
-			log.Fatalf("Failed to retrieve the task status: %s", err)
+			printApiErrorGuidance(err)
+			log.Errorf("Failed to retrieve the task status: %s", err)
+			summary.Error = err.Error()
+			writeStepSummary(env, summary)
+			return sonarscanner.ExitCode(err)
+		}
+
+		summary.Status = &status
+		writeStepSummary(env, summary)
+		writeStatusOutputs(env, &status)
+
+		logTaskDetails(status.TaskDetails)
+		annotateTaskDetails(env, status.TaskDetails)
+
+		if status.TaskStatus == sonarscanner.TaskStatusSuccess {
+			printQualityGateReport(status.QualityGateReport)
 		}
 
-		taskStatus := status.TaskStatus
-		if taskStatus != sonarscanner.TaskStatusSuccess {
-			log.Fatalf("Analysis task failed with the status '%s'", taskStatus)
+		exitCode, err := failurePolicy.Evaluate(status)
+		if err != nil {
+			if status.TaskStatus == sonarscanner.TaskStatusSuccess {
+				annotateFailedQualityGate(env, status.QualityGateReport)
+			}
+
+			log.Errorf("Action failed: %s", err)
+			return exitCode
 		}
 
 		analysisStatus := status.AnalysisStatus
-		if analysisStatus == sonarscanner.AnalysisStatusError {
-			log.Fatalf("Quality gate failed with the status '%s'", analysisStatus)
+		if analysisStatus != sonarscanner.AnalysisStatusOk {
+			log.Warnf("Quality gate status '%s' doesn't fail the action with the fail-on policy '%s'", analysisStatus, failurePolicy)
+		} else {
+			log.Infof("Quality gate status '%s'", analysisStatus)
 		}
+	} else {
+		writeStepSummary(env, summary)
+	}
+
+	log.Infof("Done")
+	return sonarscanner.ExitCodeSuccess
+}
+
+// serveProxy serves the sonar host proxy until the process is interrupted.
+func serveProxy(scannerRun *sonarscanner.Run) int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-		log.Infof("Quality gate status '%s'", analysisStatus)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			log.Infof("Received %s", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.Info("Serving the sonar host proxy until interrupted ...")
+	if err := scannerRun.ServeProxy(ctx); err != nil {
+		log.Errorf("Sonar host proxy failed: %s", err)
+		return sonarscanner.ExitCode(err)
 	}
 
 	log.Infof("Done")
+	return sonarscanner.ExitCodeSuccess
+}
+
+// maskSecrets makes the masker hide the secrets in the log output and, when run
+// by GitHub Actions, asks the runner to do the same.
+func maskSecrets(env *environment.Environment, masker *masking.Masker, secrets ...string) {
+	values := masker.Add(secrets...)
+	if !env.GithubActions {
+		return
+	}
+
+	for _, value := range values {
+		if err := github.AddMask(os.Stdout, value); err != nil {
+			log.Warnf("Failed to mask a secret: %s", err)
+		}
+	}
+}
+
+func getBranchParameters(env *environment.Environment) (*sonarscanner.BranchParameters, error) {
+	if !env.DetectBranch || !env.GithubActions {
+		return nil, nil
+	}
+
+	eventContext := &github.EventContext{
+		EventName: env.GithubEventName,
+		EventPath: env.GithubEventPath,
+		Ref:       env.GithubRef,
+		HeadRef:   env.GithubHeadRef,
+	}
+
+	return eventContext.BranchParameters()
+}
+
+func logTaskDetails(details sonarscanner.TaskDetails) {
+	if !details.SubmittedAt.IsZero() {
+		log.Infof(
+			"Analysis task submitted at %s was processed in %s",
+			details.SubmittedAt.Format(time.RFC3339),
+			details.ExecutionTime,
+		)
+	}
+
+	for _, warning := range details.Warnings {
+		log.Warnf("Analysis task warning: %s", warning)
+	}
+
+	if details.ErrorMessage != "" {
+		log.Errorf("Analysis task error: %s", details.ErrorMessage)
+	}
+
+	if details.ErrorStacktrace != "" {
+		log.Debugf("Analysis task error stacktrace:\n%s", details.ErrorStacktrace)
+	}
+}
+
+func printQualityGateReport(report sonarscanner.QualityGateReport) {
+	if len(report.Conditions) == 0 {
+		log.Info("Quality gate has no conditions")
+		return
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := tabwriter.NewWriter(buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "METRIC\tCOMPARATOR\tTHRESHOLD\tACTUAL\tSTATUS")
+	for _, condition := range report.Conditions {
+		fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\n",
+			condition.MetricKey,
+			condition.Comparator,
+			condition.ErrorThreshold,
+			condition.ActualValue,
+			condition.Status,
+		)
+	}
+	writer.Flush()
+
+	log.Info("Quality gate conditions:")
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		log.Info(line)
+	}
+}
+
+func writeStepSummary(env *environment.Environment, summary *github.StepSummary) {
+	if env.GithubStepSummary == "" {
+		return
+	}
+
+	if err := github.AppendStepSummary(env.GithubStepSummary, summary.Markdown()); err != nil {
+		log.Warnf("Failed to write the job summary: %s", err)
+	}
+}
+
+// writeOutputs writes the outputs describing the analysis task, which are known
+// before its status is retrieved.
+func writeOutputs(env *environment.Environment, reportTask *sonarscanner.ReportTask) {
+	appendOutputs(env, map[string]string{
+		"ce-task-id":    reportTask.CeTaskId,
+		"dashboard-url": reportTask.DashboardUrl,
+		"project-key":   reportTask.ProjectKey,
+	})
+}
+
+// writeStatusOutputs writes the outputs describing the analysis task status,
+// which are left empty unless it's retrieved.
+func writeStatusOutputs(env *environment.Environment, status *sonarscanner.ProjectAnalysisStatus) {
+	outputs := map[string]string{
+		"quality-gate-status": "",
+		"task-status":         status.TaskStatus.String(),
+		"analysis-id":         status.AnalysisId,
+	}
+	if status.TaskStatus == sonarscanner.TaskStatusSuccess {
+		outputs["quality-gate-status"] = status.AnalysisStatus.String()
+	}
+
+	appendOutputs(env, outputs)
+}
+
+func appendOutputs(env *environment.Environment, outputs map[string]string) {
+	if env.GithubOutput == "" {
+		return
+	}
+
+	if err := github.AppendOutputs(env.GithubOutput, outputs); err != nil {
+		log.Warnf("Failed to write the action outputs: %s", err)
+	}
+}
+
+// printApiErrorGuidance suggests how to fix the configuration if the SonarQube
+// API request failed because of it.
+func printApiErrorGuidance(err error) {
+	var unauthorizedError *sonarapi.UnauthorizedError
+	var forbiddenError *sonarapi.ForbiddenError
+	var notFoundError *sonarapi.NotFoundError
+	var htmlResponseError *sonarapi.HtmlResponseError
+
+	switch {
+	case errors.As(err, &unauthorizedError):
+		log.Error(
+			"SonarQube didn't accept the credentials, make sure that the sonar-login input holds " +
+				"a valid token, or a login with the matching sonar-password, and that the token " +
+				"hasn't expired or been revoked",
+		)
+	case errors.As(err, &forbiddenError):
+		log.Error(
+			"The SonarQube user the credentials belong to lacks the permissions to read the " +
+				"analysis results, grant it the 'Browse' permission on the project",
+		)
+	case errors.As(err, &notFoundError):
+		log.Error(
+			"SonarQube couldn't find the analysis task or its results, make sure that the " +
+				"sonar-host-url input points to the server the analysis was submitted to and " +
+				"that the user the credentials belong to can see the project",
+		)
+	case errors.As(err, &htmlResponseError):
+		log.Error(
+			"SonarQube API returned a web page, make sure that the sonar-host-url input points " +
+				"to the SonarQube server rather than to a single sign-on proxy login page, or " +
+				"that the proxy lets the API requests authenticated with a token through",
+		)
+	}
+}
+
+func annotateTaskDetails(env *environment.Environment, details sonarscanner.TaskDetails) {
+	if !env.GithubActions {
+		return
+	}
+
+	for _, warning := range details.Warnings {
+		if err := github.Warning(os.Stdout, fmt.Sprintf("SonarQube analysis warning: %s", warning)); err != nil {
+			log.Warnf("Failed to annotate the workflow run: %s", err)
+		}
+	}
+
+	if details.ErrorMessage != "" {
+		if err := github.Error(os.Stdout, fmt.Sprintf("SonarQube analysis failed: %s", details.ErrorMessage)); err != nil {
+			log.Warnf("Failed to annotate the workflow run: %s", err)
+		}
+	}
+}
+
+func annotateFailedQualityGate(env *environment.Environment, report sonarscanner.QualityGateReport) {
+	if !env.GithubActions {
+		return
+	}
+
+	message := fmt.Sprintf("Quality gate failed with the status '%s'", report.Status)
+	for _, condition := range report.FailedConditions() {
+		message += fmt.Sprintf(
+			"\n%s: %s (%s %s)",
+			condition.MetricKey,
+			condition.ActualValue,
+			condition.Comparator,
+			condition.ErrorThreshold,
+		)
+	}
+
+	if err := github.Error(os.Stdout, message); err != nil {
+		log.Warnf("Failed to annotate the workflow run: %s", err)
+	}
 }
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarscanner"
+	"github.com/stretchr/testify/assert"
+)
+
+// setTestEnvironment sets the environment variables for the duration of the
+// test and silences the log output.
+func setTestEnvironment(t *testing.T, variables map[string]string) {
+	for name, value := range variables {
+		originalValue, ok := os.LookupEnv(name)
+		os.Setenv(name, value)
+
+		name := name
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(name, originalValue)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+
+	originalOut := log.Out
+	originalStderr := stderr
+	log.Out = ioutil.Discard
+	stderr = ioutil.Discard
+	t.Cleanup(func() {
+		log.Out = originalOut
+		stderr = originalStderr
+	})
+}
+
+func TestRunWithInvalidEnvironment(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"QUALITY_GATE_WAIT_TIMEOUT": "forever",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run(nil))
+}
+
+func TestRunWithInvalidFailurePolicy(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+		"FAIL_ON":        "always",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run(nil))
+}
+
+func TestRunWithInvalidPollStrategy(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+		"POLL_STRATEGY":  "random",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run(nil))
+}
+
+func TestRunWithoutSonarHostUrl(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run(nil))
+}
+
+func TestRunWithoutSonarScanner(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+		"PATH":           t.TempDir(),
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeScannerError, run(nil))
+}
+
+func TestRunWithMalformedSonarHostUrl(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "sonarqube.local:9000",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run(nil))
+}
+
+func TestRunWithUnknownCommand(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run([]string{"deploy"}))
+}
+
+func TestRunScanWithTask(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+		"CE_TASK_ID":     "task-id",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run(nil))
+}
+
+func TestRunWaitOnlyWithoutTask(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+		"MODE":           "wait-only",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run(nil))
+}
+
+func TestRunWaitOnly(t *testing.T) {
+	server := newTestSonarQube(t, "SUCCESS", "OK")
+	defer server.Close()
+
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": server.URL,
+		"CE_TASK_ID":     "task-id",
+		"PATH":           t.TempDir(),
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeSuccess, run([]string{"wait"}))
+}
+
+func TestRunWaitOnlyWithReportTaskFile(t *testing.T) {
+	server := newTestSonarQube(t, "SUCCESS", "ERROR")
+	defer server.Close()
+
+	reportTaskFile := path.Join(t.TempDir(), "report-task.txt")
+	ioutil.WriteFile(reportTaskFile, []byte(fmt.Sprintf(
+		"serverUrl=%s\nceTaskId=task-id\nceTaskUrl=%s/api/ce/task?id=task-id\n",
+		server.URL,
+		server.URL,
+	)), 0644)
+
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL":   "",
+		"MODE":             "wait-only",
+		"REPORT_TASK_FILE": reportTaskFile,
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeQualityGateFailed, run(nil))
+}
+
+func TestRunWaitOnlyWithFailedTask(t *testing.T) {
+	server := newTestSonarQube(t, "FAILED", "")
+	defer server.Close()
+
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": server.URL,
+		"CE_TASK_ID":     "task-id",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeTaskFailed, run([]string{"wait"}))
+}
+
+func TestRunGate(t *testing.T) {
+	server := newTestSonarQube(t, "SUCCESS", "WARN")
+	defer server.Close()
+
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeQualityGateFailed, run([]string{
+		"gate",
+		"--sonar-host-url", server.URL,
+		"--ce-task-id", "task-id",
+		"--fail-on", "warn",
+	}))
+}
+
+func TestRunGateOfUnfinishedTask(t *testing.T) {
+	server := newTestSonarQube(t, "IN_PROGRESS", "")
+	defer server.Close()
+
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": server.URL,
+		"CE_TASK_ID":     "task-id",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeWaitTimeout, run([]string{"gate"}))
+}
+
+func TestRunGateOfUnfinishedTaskWritesSummaryAndOutputs(t *testing.T) {
+	server := newTestSonarQube(t, "IN_PROGRESS", "")
+	defer server.Close()
+
+	stepSummaryFile := path.Join(t.TempDir(), "step-summary.md")
+	outputFile := path.Join(t.TempDir(), "output")
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL":      server.URL,
+		"CE_TASK_ID":          "task-id",
+		"GITHUB_STEP_SUMMARY": stepSummaryFile,
+		"GITHUB_OUTPUT":       outputFile,
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeWaitTimeout, run([]string{"gate"}))
+
+	stepSummary, err := ioutil.ReadFile(stepSummaryFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(stepSummary), "| Task | `task-id` |")
+	assert.Contains(t, string(stepSummary), "### Error")
+
+	output, err := ioutil.ReadFile(outputFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(output), "ce-task-id=task-id\n")
+	assert.NotContains(t, string(output), "task-status=")
+}
+
+func TestRunValidateConfig(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run([]string{"validate-config"}))
+	assert.Equal(t, sonarscanner.ExitCodeSuccess, run([]string{
+		"validate-config",
+		"--sonar-host-url=http://sonarqube.local",
+	}))
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run([]string{
+		"validate-config",
+		"--sonar-host-url=http://sonarqube.local",
+		"--poll-strategy=random",
+	}))
+}
+
+func TestRunProxyWithNonLoopbackListenAddr(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+		"SONAR_LOGIN":    "token",
+		"PROXY_TOKEN":    "proxy-token",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run([]string{"proxy", "--proxy-listen-addr", "0.0.0.0:0"}))
+}
+
+func TestRunWithHelp(t *testing.T) {
+	setTestEnvironment(t, map[string]string{})
+
+	assert.Equal(t, sonarscanner.ExitCodeSuccess, run([]string{"--help"}))
+	assert.Equal(t, sonarscanner.ExitCodeSuccess, run([]string{"wait", "-h"}))
+}
+
+func TestRunWithInvalidFlags(t *testing.T) {
+	setTestEnvironment(t, map[string]string{
+		"SONAR_HOST_URL": "http://sonarqube.local",
+	})
+
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run([]string{"--no-such-flag"}))
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run([]string{"scan", "--request-max-attempts", "many"}))
+	assert.Equal(t, sonarscanner.ExitCodeConfigError, run([]string{"validate-config", "extra"}))
+}
+
+// newTestSonarQube returns a server responding to the task and quality gate
+// status requests with the given statuses.
+func newTestSonarQube(t *testing.T, taskStatus, qualityGateStatus string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+
+		switch req.URL.Path {
+		case "/api/ce/task":
+			assert.Equal(t, "task-id", req.URL.Query().Get("id"))
+			fmt.Fprintf(res, `{"task": {"id": "task-id", "status": "%s", "analysisId": "analysis-id"}}`, taskStatus)
+		case "/api/qualitygates/project_status":
+			assert.Equal(t, "analysis-id", req.URL.Query().Get("analysisId"))
+			fmt.Fprintf(res, `{"projectStatus": {"status": "%s"}}`, qualityGateStatus)
+		default:
+			res.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/environment"
+)
+
+const binaryName = "sonar-scanner-action"
+
+const (
+	// modeScan runs sonar-scanner and waits for the analysis results.
+	modeScan = "scan"
+	// modeWaitOnly only waits for the results of an analysis made by some
+	// other tool.
+	modeWaitOnly = "wait-only"
+)
+
+const (
+	commandScan           = "scan"
+	commandWait           = "wait"
+	commandGate           = "gate"
+	commandProxy          = "proxy"
+	commandValidateConfig = "validate-config"
+)
+
+// commands lists the commands in the order they are printed in the help.
+var commands = []struct {
+	name        string
+	description string
+}{
+	{commandScan, "run sonar-scanner and check the quality gate, the default unless MODE is wait-only"},
+	{commandWait, "wait for an existing analysis task to finish and check the quality gate"},
+	{commandGate, "check the quality gate of an analysis task that has already finished"},
+	{commandProxy, "serve the sonar host proxy for the other scanners until interrupted"},
+	{commandValidateConfig, "check the configuration without contacting the server"},
+}
+
+// stderr is where the help is printed to.
+var stderr io.Writer = os.Stderr
+
+// splitCommand splits the command line arguments into the command name, which
+// is empty if the arguments start with a flag, and the rest of the arguments.
+func splitCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", args
+	}
+
+	return args[0], args[1:]
+}
+
+// getCommand returns the command to run, inferring it from the MODE environment
+// variable if no command is given, and checks that the settings the command
+// requires are set.
+func getCommand(env *environment.Environment, name string) (string, error) {
+	command := name
+	if command == "" {
+		switch env.Mode {
+		case modeScan:
+			command = commandScan
+		case modeWaitOnly:
+			command = commandWait
+		default:
+			return "", fmt.Errorf("unknown mode '%s'", env.Mode)
+		}
+	}
+
+	switch command {
+	case commandScan, commandProxy:
+		// The analysis to check is the one sonar-scanner submits, so an
+		// existing one can't be given.
+		if env.ReportTaskFile != "" || env.CeTaskId != "" {
+			return "", fmt.Errorf("the %s command doesn't accept the report task file or the task id", command)
+		}
+
+		// Unlike sonar-scanner, the proxy clients can't be given a random
+		// token.
+		if command == commandProxy && env.ProxyToken == "" {
+			return "", fmt.Errorf("the %s command requires the PROXY_TOKEN the proxy clients authenticate with", command)
+		}
+
+		return command, nil
+	case commandValidateConfig:
+		return command, nil
+	case commandWait, commandGate:
+		if env.ReportTaskFile == "" && env.CeTaskId == "" {
+			return "", fmt.Errorf("the %s command requires either the report task file or the task id", command)
+		}
+
+		return command, nil
+	default:
+		return "", fmt.Errorf("unknown command '%s'", command)
+	}
+}
+
+// printUsage prints the help of the command, listing all the commands if none
+// is given.
+func printUsage(flags *flag.FlagSet, name string) {
+	out := flags.Output()
+	if name != "" {
+		fmt.Fprintf(out, "Usage: %s %s [flags]\n", binaryName, name)
+	} else {
+		fmt.Fprintf(out, "Usage: %s [command] [flags]\n\nCommands:\n", binaryName)
+		for _, command := range commands {
+			fmt.Fprintf(out, "  %-17s %s\n", command.name, command.description)
+		}
+	}
+
+	fmt.Fprint(out, "\nFlags, each one defaults to the value of the environment variable in parentheses:\n")
+	flags.PrintDefaults()
+
+	fmt.Fprint(out, "\nSecrets, read only from the environment variables, never from the flags:\n")
+	environment.PrintSecretUsages(out)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/environment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCommand(t *testing.T) {
+	name, args := splitCommand([]string{"wait", "--ce-task-id", "task-id"})
+	assert.Equal(t, "wait", name)
+	assert.Equal(t, []string{"--ce-task-id", "task-id"}, args)
+
+	name, args = splitCommand([]string{"--log-level", "debug"})
+	assert.Equal(t, "", name)
+	assert.Equal(t, []string{"--log-level", "debug"}, args)
+
+	name, args = splitCommand(nil)
+	assert.Equal(t, "", name)
+	assert.Empty(t, args)
+}
+
+func TestGetCommand(t *testing.T) {
+	env := &environment.Environment{Mode: modeScan, ProxyToken: "proxy-token"}
+
+	for _, name := range []string{commandScan, commandProxy, commandValidateConfig} {
+		command, err := getCommand(env, name)
+		assert.Nil(t, err)
+		assert.Equal(t, name, command)
+	}
+
+	command, err := getCommand(env, "")
+	assert.Nil(t, err)
+	assert.Equal(t, commandScan, command)
+
+	_, err = getCommand(env, "deploy")
+	assert.NotNil(t, err)
+}
+
+func TestGetCommandRequiringTask(t *testing.T) {
+	env := &environment.Environment{Mode: modeWaitOnly}
+
+	_, err := getCommand(env, "")
+	assert.NotNil(t, err)
+
+	_, err = getCommand(env, commandGate)
+	assert.NotNil(t, err)
+
+	env.CeTaskId = "task-id"
+
+	command, err := getCommand(env, "")
+	assert.Nil(t, err)
+	assert.Equal(t, commandWait, command)
+
+	command, err = getCommand(env, commandGate)
+	assert.Nil(t, err)
+	assert.Equal(t, commandGate, command)
+}
+
+func TestGetCommandRejectingTask(t *testing.T) {
+	for _, env := range []*environment.Environment{
+		{Mode: modeScan, CeTaskId: "task-id"},
+		{Mode: modeScan, ReportTaskFile: "target/sonar/report-task.txt"},
+	} {
+		_, err := getCommand(env, "")
+		assert.NotNil(t, err)
+
+		_, err = getCommand(env, commandProxy)
+		assert.NotNil(t, err)
+
+		command, err := getCommand(env, commandValidateConfig)
+		assert.Nil(t, err)
+		assert.Equal(t, commandValidateConfig, command)
+	}
+}
+
+func TestGetCommandRequiringProxyToken(t *testing.T) {
+	_, err := getCommand(&environment.Environment{Mode: modeScan}, commandProxy)
+
+	assert.NotNil(t, err)
+}
+
+func TestGetCommandWithUnknownMode(t *testing.T) {
+	_, err := getCommand(&environment.Environment{Mode: "deploy"}, "")
+
+	assert.NotNil(t, err)
+}
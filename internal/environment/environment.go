@@ -1,6 +1,7 @@
 package environment
 
 import (
+	"flag"
 	"fmt"
 	"reflect"
 	"time"
@@ -10,18 +11,91 @@ import (
 )
 
 type Environment struct {
+	Mode                   string        `env:"MODE" envDefault:"scan"`
+	ReportTaskFile         string        `env:"REPORT_TASK_FILE" envDefault:""`
+	CeTaskId               string        `env:"CE_TASK_ID" envDefault:""`
 	SonarHostUrl           string        `env:"SONAR_HOST_URL"`
 	SonarHostCert          string        `env:"SONAR_HOST_CERT"`
+	SonarClientCert        string        `env:"SONAR_CLIENT_CERT" envDefault:""`
+	SonarClientKey         string        `env:"SONAR_CLIENT_KEY" envDefault:"" secret:"true"`
+	SonarClientKeyPassword string        `env:"SONAR_CLIENT_KEY_PASSWORD" envDefault:"" secret:"true"`
 	ProjectFileLocation    string        `env:"PROJECT_FILE_LOCATION" envDefault:""`
 	WaitForQualityGate     bool          `env:"WAIT_FOR_QUALITY_GATE" envDefault:"true"`
 	QualityGateWaitTimeout time.Duration `env:"QUALITY_GATE_WAIT_TIMEOUT" envDefault:"2m"`
+	FailOn                 string        `env:"FAIL_ON" envDefault:"error"`
+	PollStrategy           string        `env:"POLL_STRATEGY" envDefault:"fixed"`
+	PollInterval           time.Duration `env:"POLL_INTERVAL" envDefault:"2s"`
+	PollMaxInterval        time.Duration `env:"POLL_MAX_INTERVAL" envDefault:"30s"`
+	PollJitter             float64       `env:"POLL_JITTER" envDefault:"0"`
+	RequestMaxAttempts     int           `env:"REQUEST_MAX_ATTEMPTS" envDefault:"4"`
+	RequestRetryInterval   time.Duration `env:"REQUEST_RETRY_INTERVAL" envDefault:"1s"`
 	LogLevel               logrus.Level  `env:"LOG_LEVEL" envDefault:"info"`
 	TlsSkipVerify          bool          `env:"TLS_SKIP_VERIFY" envDefault:"false"`
-	SonarLogin             string        `env:"SONAR_LOGIN" envDefault:""`
-	SonarPassword          string        `env:"SONAR_PASSWORD" envDefault:""`
+	SonarLogin             string        `env:"SONAR_LOGIN" envDefault:"" secret:"true"`
+	SonarPassword          string        `env:"SONAR_PASSWORD" envDefault:"" secret:"true"`
+	SonarHttpProxy         string        `env:"SONAR_HTTP_PROXY" envDefault:""`
+	ProxyListenAddr        string        `env:"PROXY_LISTEN_ADDR" envDefault:""`
+	ProxyToken             string        `env:"PROXY_TOKEN" envDefault:"" secret:"true"`
+	ScannerArgs            string        `env:"SCANNER_ARGS" envDefault:""`
+	ScannerPath            string        `env:"SCANNER_PATH" envDefault:""`
+	ScannerWorkingDir      string        `env:"SCANNER_WORKING_DIR" envDefault:""`
+	DetectBranch           bool          `env:"DETECT_BRANCH" envDefault:"true"`
+	GithubActions          bool          `env:"GITHUB_ACTIONS" envDefault:"false"`
+	GithubStepSummary      string        `env:"GITHUB_STEP_SUMMARY" envDefault:""`
+	GithubOutput           string        `env:"GITHUB_OUTPUT" envDefault:""`
+	GithubEventName        string        `env:"GITHUB_EVENT_NAME" envDefault:""`
+	GithubEventPath        string        `env:"GITHUB_EVENT_PATH" envDefault:""`
+	GithubRef              string        `env:"GITHUB_REF" envDefault:""`
+	GithubHeadRef          string        `env:"GITHUB_HEAD_REF" envDefault:""`
 }
 
+// Secrets returns the non-empty values of the fields marked with the
+// `secret:"true"` tag, which must never appear in the action output.
+func (e *Environment) Secrets() []string {
+	var secrets []string
+
+	value := reflect.ValueOf(e).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).Tag.Get("secret") != "true" {
+			continue
+		}
+
+		if secret := value.Field(i).String(); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	return secrets
+}
+
+// Get returns the environment read from the process environment variables
+// alone, it's Parse without the command line flags.
 func Get() (*Environment, error) {
+	return Parse(flag.NewFlagSet("environment", flag.ContinueOnError), nil)
+}
+
+// Parse returns the environment read from the process environment variables,
+// which are overridden by the command line flags parsed from the args. A flag is
+// registered in the flag set for every field, see RegisterFlags.
+func Parse(flags *flag.FlagSet, args []string) (*Environment, error) {
+	environment, err := parse()
+	if err != nil {
+		return nil, err
+	}
+
+	environment.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := environment.validate(); err != nil {
+		return nil, err
+	}
+
+	return environment, nil
+}
+
+func parse() (*Environment, error) {
 	parsers := env.CustomParsers{
 		reflect.TypeOf(logrus.DebugLevel): func(str string) (interface{}, error) {
 			return logrus.ParseLevel(str)
@@ -33,9 +107,13 @@ func Get() (*Environment, error) {
 		return nil, err
 	}
 
-	if environment.QualityGateWaitTimeout <= time.Duration(0) {
-		return nil, fmt.Errorf("quality gate wait timeout must be positive")
+	return environment, nil
+}
+
+func (e *Environment) validate() error {
+	if e.QualityGateWaitTimeout <= time.Duration(0) {
+		return fmt.Errorf("quality gate wait timeout must be positive")
 	}
 
-	return environment, nil
+	return nil
 }
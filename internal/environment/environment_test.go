@@ -15,14 +15,41 @@ func TestGet(t *testing.T) {
 	e, err := Get()
 
 	assert.Nil(t, err)
+	assert.Equal(t, e.Mode, "wait-only")
+	assert.Equal(t, e.ReportTaskFile, "target/sonar/report-task.txt")
+	assert.Equal(t, e.CeTaskId, "task-id")
 	assert.Equal(t, e.SonarHostCert, "sonar-host-cert")
 	assert.Equal(t, e.SonarHostUrl, "sonar-host-url")
+	assert.Equal(t, e.SonarClientCert, "sonar-client-cert")
+	assert.Equal(t, e.SonarClientKey, "sonar-client-key")
+	assert.Equal(t, e.SonarClientKeyPassword, "sonar-client-key-password")
 	assert.Equal(t, e.ProjectFileLocation, "project-file-location")
 	assert.Equal(t, e.WaitForQualityGate, true)
 	assert.Equal(t, e.QualityGateWaitTimeout, 10*time.Second)
+	assert.Equal(t, e.FailOn, "warn")
+	assert.Equal(t, e.PollStrategy, "exponential")
+	assert.Equal(t, e.PollInterval, time.Second)
+	assert.Equal(t, e.PollMaxInterval, 20*time.Second)
+	assert.Equal(t, e.PollJitter, 0.2)
+	assert.Equal(t, e.RequestMaxAttempts, 2)
+	assert.Equal(t, e.RequestRetryInterval, 3*time.Second)
 	assert.Equal(t, e.LogLevel, logrus.WarnLevel)
 	assert.Equal(t, e.SonarLogin, "sonar-login")
 	assert.Equal(t, e.SonarPassword, "sonar-password")
+	assert.Equal(t, e.GithubActions, true)
+	assert.Equal(t, e.GithubStepSummary, "/github/step-summary.md")
+	assert.Equal(t, e.GithubOutput, "/github/output")
+	assert.Equal(t, e.SonarHttpProxy, "http://proxy.local:3128")
+	assert.Equal(t, e.ProxyListenAddr, "localhost:9000")
+	assert.Equal(t, e.ProxyToken, "proxy-token")
+	assert.Equal(t, e.ScannerArgs, "-Dsonar.projectVersion=1.0")
+	assert.Equal(t, e.ScannerPath, "/opt/sonar-scanner")
+	assert.Equal(t, e.ScannerWorkingDir, "/tmp/scannerwork")
+	assert.Equal(t, e.DetectBranch, false)
+	assert.Equal(t, e.GithubEventName, "pull_request")
+	assert.Equal(t, e.GithubEventPath, "/github/event.json")
+	assert.Equal(t, e.GithubRef, "refs/pull/42/merge")
+	assert.Equal(t, e.GithubHeadRef, "feature")
 }
 
 func TestGetParseFailed(t *testing.T) {
@@ -47,13 +74,60 @@ func TestGetInvalidDuration(t *testing.T) {
 	assert.Nil(t, e)
 }
 
+func TestSecrets(t *testing.T) {
+	setEnvironment()
+
+	e, err := Get()
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"sonar-client-key",
+		"sonar-client-key-password",
+		"sonar-login",
+		"sonar-password",
+		"proxy-token",
+	}, e.Secrets())
+
+	e.SonarPassword = ""
+	e.ProxyToken = ""
+
+	assert.Equal(t, []string{"sonar-client-key", "sonar-client-key-password", "sonar-login"}, e.Secrets())
+}
+
 func setEnvironment() {
 	os.Setenv("SONAR_HOST_URL", "sonar-host-url")
+	os.Setenv("MODE", "wait-only")
+	os.Setenv("REPORT_TASK_FILE", "target/sonar/report-task.txt")
+	os.Setenv("CE_TASK_ID", "task-id")
 	os.Setenv("SONAR_HOST_CERT", "sonar-host-cert")
+	os.Setenv("SONAR_CLIENT_CERT", "sonar-client-cert")
+	os.Setenv("SONAR_CLIENT_KEY", "sonar-client-key")
+	os.Setenv("SONAR_CLIENT_KEY_PASSWORD", "sonar-client-key-password")
 	os.Setenv("PROJECT_FILE_LOCATION", "project-file-location")
 	os.Setenv("WAIT_FOR_QUALITY_GATE", "true")
 	os.Setenv("QUALITY_GATE_WAIT_TIMEOUT", "10s")
+	os.Setenv("FAIL_ON", "warn")
+	os.Setenv("POLL_STRATEGY", "exponential")
+	os.Setenv("POLL_INTERVAL", "1s")
+	os.Setenv("POLL_MAX_INTERVAL", "20s")
+	os.Setenv("POLL_JITTER", "0.2")
+	os.Setenv("REQUEST_MAX_ATTEMPTS", "2")
+	os.Setenv("REQUEST_RETRY_INTERVAL", "3s")
 	os.Setenv("LOG_LEVEL", "warning")
 	os.Setenv("SONAR_LOGIN", "sonar-login")
 	os.Setenv("SONAR_PASSWORD", "sonar-password")
+	os.Setenv("GITHUB_ACTIONS", "true")
+	os.Setenv("GITHUB_STEP_SUMMARY", "/github/step-summary.md")
+	os.Setenv("GITHUB_OUTPUT", "/github/output")
+	os.Setenv("SONAR_HTTP_PROXY", "http://proxy.local:3128")
+	os.Setenv("PROXY_LISTEN_ADDR", "localhost:9000")
+	os.Setenv("PROXY_TOKEN", "proxy-token")
+	os.Setenv("SCANNER_ARGS", "-Dsonar.projectVersion=1.0")
+	os.Setenv("SCANNER_PATH", "/opt/sonar-scanner")
+	os.Setenv("SCANNER_WORKING_DIR", "/tmp/scannerwork")
+	os.Setenv("DETECT_BRANCH", "false")
+	os.Setenv("GITHUB_EVENT_NAME", "pull_request")
+	os.Setenv("GITHUB_EVENT_PATH", "/github/event.json")
+	os.Setenv("GITHUB_REF", "refs/pull/42/merge")
+	os.Setenv("GITHUB_HEAD_REF", "feature")
 }
@@ -0,0 +1,163 @@
+package environment
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// usages describe the environment variables in the command line help.
+var usages = map[string]string{
+	"MODE":                      "either `scan` to run sonar-scanner or wait-only to wait for an existing analysis",
+	"REPORT_TASK_FILE":          "the `path` of the report-task.txt file of the analysis to wait for",
+	"CE_TASK_ID":                "the `id` of the analysis task to wait for",
+	"SONAR_HOST_URL":            "the `url` of the SonarQube server",
+	"SONAR_HOST_CERT":           "the PEM encoded `certificate` the SonarQube server certificate is signed with",
+	"SONAR_CLIENT_CERT":         "the PEM encoded client `certificate` presented to the SonarQube server",
+	"SONAR_CLIENT_KEY":          "the PEM encoded private `key` of the client certificate",
+	"SONAR_CLIENT_KEY_PASSWORD": "the `password` the client certificate private key is encrypted with",
+	"PROJECT_FILE_LOCATION":     "the `path` of the sonar-project.properties file",
+	"WAIT_FOR_QUALITY_GATE":     "whether to wait for the analysis task and check the quality gate",
+	"QUALITY_GATE_WAIT_TIMEOUT": "the maximum `duration` to wait for the analysis task",
+	"FAIL_ON":                   "the quality gate `statuses` failing the run: error, warn, none or never",
+	"POLL_STRATEGY":             "the way the analysis task is polled, either `fixed` or exponential",
+	"POLL_INTERVAL":             "the `duration` between the analysis task polls",
+	"POLL_MAX_INTERVAL":         "the maximum `duration` between the exponential analysis task polls",
+	"POLL_JITTER":               "the `fraction` of the poll interval it's randomly changed by",
+	"REQUEST_MAX_ATTEMPTS":      "the maximum `number` of attempts of a failed SonarQube request",
+	"REQUEST_RETRY_INTERVAL":    "the `duration` before the first retry of a failed SonarQube request",
+	"LOG_LEVEL":                 "the log `level`",
+	"TLS_SKIP_VERIFY":           "whether to skip the SonarQube server certificate verification",
+	"SONAR_LOGIN":               "the SonarQube `login` or token",
+	"SONAR_PASSWORD":            "the SonarQube `password`",
+	"SONAR_HTTP_PROXY":          "the `url` of the HTTP proxy used to connect to the SonarQube server",
+	"PROXY_LISTEN_ADDR":         "the `address` the sonar host proxy listens on, a free localhost port by default, must be a loopback one if the credentials or the client certificate are set",
+	"PROXY_TOKEN":               "the `token` the clients of the proxy command authenticate with, either as the basic auth login or as the bearer token",
+	"SCANNER_ARGS":              "the extra sonar-scanner `arguments`",
+	"SCANNER_PATH":              "the `path` of the sonar-scanner executable or installation directory, looked up in the PATH by default",
+	"SCANNER_WORKING_DIR":       "the sonar-scanner working `directory`, .scannerwork by default",
+	"DETECT_BRANCH":             "whether to infer the branch or pull request parameters from the GitHub event",
+	"GITHUB_ACTIONS":            "whether the run is a GitHub Actions workflow step",
+	"GITHUB_STEP_SUMMARY":       "the `path` of the GitHub Actions job summary file",
+	"GITHUB_OUTPUT":             "the `path` of the GitHub Actions step outputs file",
+	"GITHUB_EVENT_NAME":         "the `name` of the event that triggered the GitHub Actions workflow",
+	"GITHUB_EVENT_PATH":         "the `path` of the GitHub Actions event payload file",
+	"GITHUB_REF":                "the git `ref` the GitHub Actions workflow runs for",
+	"GITHUB_HEAD_REF":           "the pull request head `branch`",
+}
+
+// fieldValue is a flag.Value setting an environment field.
+type fieldValue struct {
+	value reflect.Value
+}
+
+// RegisterFlags registers a flag for every environment field, named after its
+// environment variable, e.g. --sonar-host-url for SONAR_HOST_URL. The flags
+// default to the current field values. The secrets have no flags, since the
+// command line is visible to the other processes, they are read from the
+// environment variables alone.
+func (e *Environment) RegisterFlags(flags *flag.FlagSet) {
+	value := reflect.ValueOf(e).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Tag.Get("secret") == "true" {
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		flags.Var(
+			&fieldValue{value: value.Field(i)},
+			FlagName(name),
+			fmt.Sprintf("%s (%s)", usages[name], name),
+		)
+	}
+}
+
+// PrintSecretUsages prints the environment variables of the secrets, which have
+// no flags, along with their descriptions in the format of the flags help.
+func PrintSecretUsages(out io.Writer) {
+	fields := reflect.TypeOf(Environment{})
+	for i := 0; i < fields.NumField(); i++ {
+		if fields.Field(i).Tag.Get("secret") != "true" {
+			continue
+		}
+
+		name := fields.Field(i).Tag.Get("env")
+		fmt.Fprintf(out, "  %s\n    \t%s\n", name, strings.Replace(usages[name], "`", "", -1))
+	}
+}
+
+// FlagName returns the name of the flag overriding the environment variable.
+func FlagName(variable string) string {
+	return strings.ToLower(strings.Replace(variable, "_", "-", -1))
+}
+
+func (v *fieldValue) String() string {
+	if !v.value.IsValid() || v.value.IsZero() {
+		return ""
+	}
+
+	return fmt.Sprint(v.value.Interface())
+}
+
+func (v *fieldValue) Set(str string) error {
+	switch v.value.Interface().(type) {
+	case logrus.Level:
+		level, err := logrus.ParseLevel(str)
+		if err != nil {
+			return err
+		}
+
+		v.value.Set(reflect.ValueOf(level))
+		return nil
+	case time.Duration:
+		duration, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+
+		v.value.Set(reflect.ValueOf(duration))
+		return nil
+	}
+
+	switch v.value.Kind() {
+	case reflect.String:
+		v.value.SetString(str)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+
+		v.value.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(str)
+		if err != nil {
+			return err
+		}
+
+		v.value.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return err
+		}
+
+		v.value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.value.Type())
+	}
+
+	return nil
+}
+
+// IsBoolFlag lets the boolean flags be given without a value.
+func (v *fieldValue) IsBoolFlag() bool {
+	return v.value.IsValid() && v.value.Kind() == reflect.Bool
+}
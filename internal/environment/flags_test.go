@@ -0,0 +1,103 @@
+package environment
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	setEnvironment()
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	e, err := Parse(flags, []string{
+		"--sonar-host-url", "https://sonarqube.local",
+		"--wait-for-quality-gate=false",
+		"--tls-skip-verify",
+		"--quality-gate-wait-timeout", "5m",
+		"--log-level", "debug",
+		"--request-max-attempts", "7",
+		"--poll-jitter", "0.5",
+		"target/sonar/report-task.txt",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sonarqube.local", e.SonarHostUrl)
+	assert.Equal(t, false, e.WaitForQualityGate)
+	assert.Equal(t, true, e.TlsSkipVerify)
+	assert.Equal(t, 5*time.Minute, e.QualityGateWaitTimeout)
+	assert.Equal(t, logrus.DebugLevel, e.LogLevel)
+	assert.Equal(t, 7, e.RequestMaxAttempts)
+	assert.Equal(t, 0.5, e.PollJitter)
+	assert.Equal(t, []string{"target/sonar/report-task.txt"}, flags.Args())
+
+	// The fields without flags fall back to the environment variables.
+	assert.Equal(t, "sonar-login", e.SonarLogin)
+	assert.Equal(t, 20*time.Second, e.PollMaxInterval)
+}
+
+func TestParseInvalidFlag(t *testing.T) {
+	setEnvironment()
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	e, err := Parse(flags, []string{"--poll-interval", "often"})
+
+	assert.NotNil(t, err)
+	assert.Nil(t, e)
+}
+
+func TestParseInvalidDuration(t *testing.T) {
+	setEnvironment()
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	e, err := Parse(flags, []string{"--quality-gate-wait-timeout", "0s"})
+
+	assert.NotNil(t, err)
+	assert.Nil(t, e)
+}
+
+func TestRegisterFlags(t *testing.T) {
+	setEnvironment()
+
+	e, err := Get()
+	assert.Nil(t, err)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	e.RegisterFlags(flags)
+
+	fields := reflect.TypeOf(Environment{})
+	for i := 0; i < fields.NumField(); i++ {
+		variable := fields.Field(i).Tag.Get("env")
+		assert.NotEmpty(t, usages[variable], variable)
+
+		f := flags.Lookup(FlagName(variable))
+		if fields.Field(i).Tag.Get("secret") == "true" {
+			assert.Nil(t, f, variable)
+		} else {
+			assert.NotNil(t, f, variable)
+		}
+	}
+
+	assert.Equal(t, "sonar-host-url", flags.Lookup("sonar-host-url").DefValue)
+	assert.Equal(t, "10s", flags.Lookup("quality-gate-wait-timeout").DefValue)
+}
+
+func TestPrintSecretUsages(t *testing.T) {
+	var out bytes.Buffer
+	PrintSecretUsages(&out)
+
+	assert.Contains(t, out.String(), "  SONAR_PASSWORD\n    \tthe SonarQube password\n")
+	assert.NotContains(t, out.String(), "SONAR_HOST_URL")
+}
+
+func TestFlagName(t *testing.T) {
+	assert.Equal(t, "sonar-host-url", FlagName("SONAR_HOST_URL"))
+	assert.Equal(t, "mode", FlagName("MODE"))
+}
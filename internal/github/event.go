@@ -0,0 +1,84 @@
+package github
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarscanner"
+	"github.com/tidwall/gjson"
+)
+
+const branchRefPrefix = "refs/heads/"
+
+// EventContext describes the event that triggered the workflow, as reported by
+// the GITHUB_EVENT_NAME, GITHUB_EVENT_PATH, GITHUB_REF and GITHUB_HEAD_REF
+// environment variables.
+type EventContext struct {
+	EventName string
+	EventPath string
+	Ref       string
+	HeadRef   string
+}
+
+// BranchParameters returns the sonar-scanner branch or pull request parameters
+// matching the event. Nil is returned for the events not related to a branch,
+// e.g. tag pushes, and for the repository default branch, which is analyzed as
+// the main branch of the project.
+func (c *EventContext) BranchParameters() (*sonarscanner.BranchParameters, error) {
+	event, err := c.readEvent()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.EventName == "pull_request" || c.EventName == "pull_request_target" {
+		if event == nil {
+			return nil, fmt.Errorf("event payload is required to analyze the %s event", c.EventName)
+		}
+
+		number := event.Get("pull_request.number")
+		if !number.Exists() {
+			return nil, fmt.Errorf("event payload doesn't contain the pull request number")
+		}
+
+		branch := c.HeadRef
+		if branch == "" {
+			branch = event.Get("pull_request.head.ref").Str
+		}
+
+		return &sonarscanner.BranchParameters{
+			PullRequestKey:    number.String(),
+			PullRequestBranch: branch,
+			PullRequestBase:   event.Get("pull_request.base.ref").Str,
+		}, nil
+	}
+
+	if !strings.HasPrefix(c.Ref, branchRefPrefix) {
+		return nil, nil
+	}
+
+	branch := strings.TrimPrefix(c.Ref, branchRefPrefix)
+	if event != nil && branch == event.Get("repository.default_branch").Str {
+		return nil, nil
+	}
+
+	return &sonarscanner.BranchParameters{BranchName: branch}, nil
+}
+
+func (c *EventContext) readEvent() (*gjson.Result, error) {
+	if c.EventPath == "" {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(c.EventPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the event payload: %s", err)
+	}
+
+	if !gjson.ValidBytes(content) {
+		return nil, fmt.Errorf("event payload is not a valid json")
+	}
+
+	event := gjson.ParseBytes(content)
+	return &event, nil
+}
@@ -0,0 +1,150 @@
+package github
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarscanner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchParametersPullRequest(t *testing.T) {
+	context := &EventContext{
+		EventName: "pull_request",
+		EventPath: writeEvent(t, `
+        {
+            "pull_request": {
+                "number": 42,
+                "head": {"ref": "feature/head"},
+                "base": {"ref": "main"}
+            }
+        }
+        `),
+		Ref:     "refs/pull/42/merge",
+		HeadRef: "feature/branch",
+	}
+
+	params, err := context.BranchParameters()
+
+	assert.Nil(t, err)
+	assert.Equal(t, &sonarscanner.BranchParameters{
+		PullRequestKey:    "42",
+		PullRequestBranch: "feature/branch",
+		PullRequestBase:   "main",
+	}, params)
+}
+
+func TestBranchParametersPullRequestWithoutHeadRef(t *testing.T) {
+	context := &EventContext{
+		EventName: "pull_request_target",
+		EventPath: writeEvent(t, `
+        {
+            "pull_request": {
+                "number": 42,
+                "head": {"ref": "feature/head"},
+                "base": {"ref": "main"}
+            }
+        }
+        `),
+	}
+
+	params, err := context.BranchParameters()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "feature/head", params.PullRequestBranch)
+}
+
+func TestBranchParametersPullRequestWithoutNumber(t *testing.T) {
+	context := &EventContext{
+		EventName: "pull_request",
+		EventPath: writeEvent(t, `{"pull_request": {}}`),
+	}
+
+	params, err := context.BranchParameters()
+
+	assert.NotNil(t, err)
+	assert.Nil(t, params)
+}
+
+func TestBranchParametersPullRequestWithoutPayload(t *testing.T) {
+	context := &EventContext{EventName: "pull_request"}
+
+	params, err := context.BranchParameters()
+
+	assert.NotNil(t, err)
+	assert.Nil(t, params)
+}
+
+func TestBranchParametersPush(t *testing.T) {
+	context := &EventContext{
+		EventName: "push",
+		EventPath: writeEvent(t, `{"repository": {"default_branch": "main"}}`),
+		Ref:       "refs/heads/feature/branch",
+	}
+
+	params, err := context.BranchParameters()
+
+	assert.Nil(t, err)
+	assert.Equal(t, &sonarscanner.BranchParameters{BranchName: "feature/branch"}, params)
+}
+
+func TestBranchParametersPushToDefaultBranch(t *testing.T) {
+	context := &EventContext{
+		EventName: "push",
+		EventPath: writeEvent(t, `{"repository": {"default_branch": "main"}}`),
+		Ref:       "refs/heads/main",
+	}
+
+	params, err := context.BranchParameters()
+
+	assert.Nil(t, err)
+	assert.Nil(t, params)
+}
+
+func TestBranchParametersTag(t *testing.T) {
+	context := &EventContext{
+		EventName: "push",
+		Ref:       "refs/tags/v1.0.0",
+	}
+
+	params, err := context.BranchParameters()
+
+	assert.Nil(t, err)
+	assert.Nil(t, params)
+}
+
+func TestBranchParametersInvalidPayload(t *testing.T) {
+	context := &EventContext{
+		EventName: "push",
+		EventPath: writeEvent(t, "{"),
+		Ref:       "refs/heads/main",
+	}
+
+	params, err := context.BranchParameters()
+
+	assert.NotNil(t, err)
+	assert.Nil(t, params)
+}
+
+func TestBranchParametersMissingPayload(t *testing.T) {
+	context := &EventContext{
+		EventName: "push",
+		EventPath: path.Join(t.TempDir(), "non-existent.json"),
+		Ref:       "refs/heads/main",
+	}
+
+	params, err := context.BranchParameters()
+
+	assert.NotNil(t, err)
+	assert.Nil(t, params)
+}
+
+func writeEvent(t *testing.T, content string) string {
+	fileName := path.Join(t.TempDir(), "event.json")
+	if err := ioutil.WriteFile(fileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return fileName
+}
@@ -0,0 +1,38 @@
+package github
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const outputDelimiterPrefix = "ghadelimiter_"
+
+// AppendOutputs appends the step outputs to the file, which path GitHub passes
+// in the GITHUB_OUTPUT environment variable. Outputs are written in the name
+// order, multiline values use the heredoc-like syntax.
+func AppendOutputs(fileName string, outputs map[string]string) error {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	builder := &strings.Builder{}
+	for _, name := range names {
+		value := outputs[name]
+		if strings.ContainsAny(value, "\r\n") {
+			delimiter := outputDelimiterPrefix + name
+			if strings.Contains(value, delimiter) {
+				return fmt.Errorf("value of the output '%s' contains the delimiter", name)
+			}
+
+			fmt.Fprintf(builder, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter)
+		} else {
+			fmt.Fprintf(builder, "%s=%s\n", name, value)
+		}
+	}
+
+	return appendToFile(fileName, builder.String())
+}
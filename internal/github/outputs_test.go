@@ -0,0 +1,41 @@
+package github
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendOutputs(t *testing.T) {
+	fileName := path.Join(t.TempDir(), "output")
+
+	err := AppendOutputs(fileName, map[string]string{
+		"task-status":         "SUCCESS",
+		"quality-gate-status": "OK",
+		"multiline":           "first\nsecond",
+		"empty":               "",
+	})
+
+	assert.Nil(t, err)
+
+	content, _ := ioutil.ReadFile(fileName)
+
+	assert.Equal(
+		t,
+		"empty=\nmultiline<<ghadelimiter_multiline\nfirst\nsecond\nghadelimiter_multiline\n"+
+			"quality-gate-status=OK\ntask-status=SUCCESS\n",
+		string(content),
+	)
+}
+
+func TestAppendOutputsDelimiterInValue(t *testing.T) {
+	fileName := path.Join(t.TempDir(), "output")
+
+	err := AppendOutputs(fileName, map[string]string{
+		"name": "value\nghadelimiter_name\n",
+	})
+
+	assert.NotNil(t, err)
+}
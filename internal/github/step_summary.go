@@ -0,0 +1,143 @@
+package github
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarscanner"
+)
+
+var tableCellEscaper = strings.NewReplacer("|", "\\|", "\r", " ", "\n", " ")
+
+// StepSummary describes a sonar-scanner run in the form of a Markdown report
+// suitable for the GitHub Actions job summary. Status is nil when the quality
+// gate status wasn't retrieved and ScannerDuration is zero when sonar-scanner
+// wasn't run. Error is the message of the error the run failed with, if any.
+type StepSummary struct {
+	ReportTask      *sonarscanner.ReportTask
+	Status          *sonarscanner.ProjectAnalysisStatus
+	ScannerDuration time.Duration
+	WaitDuration    time.Duration
+	Error           string
+}
+
+func (s *StepSummary) Markdown() string {
+	builder := &strings.Builder{}
+
+	fmt.Fprintln(builder, "## SonarQube analysis")
+	fmt.Fprintln(builder)
+	fmt.Fprintln(builder, "| | |")
+	fmt.Fprintln(builder, "|---|---|")
+
+	if s.Status != nil {
+		fmt.Fprintf(builder, "| Quality gate | **%s** |\n", s.Status.AnalysisStatus)
+	} else {
+		fmt.Fprintln(builder, "| Quality gate | not checked |")
+	}
+
+	if s.ReportTask != nil {
+		if s.ReportTask.CeTaskId != "" {
+			fmt.Fprintf(builder, "| Task | `%s` |\n", escapeTableCell(s.ReportTask.CeTaskId))
+		}
+		if s.ReportTask.DashboardUrl != "" {
+			dashboardUrl := escapeTableCell(s.ReportTask.DashboardUrl)
+			fmt.Fprintf(builder, "| Dashboard | [%s](%s) |\n", dashboardUrl, dashboardUrl)
+		}
+	}
+
+	if s.Status != nil {
+		fmt.Fprintf(builder, "| Task status | %s |\n", s.Status.TaskStatus)
+		if details := s.Status.TaskDetails; !details.SubmittedAt.IsZero() {
+			fmt.Fprintf(builder, "| Task submitted at | %s |\n", details.SubmittedAt.Format(time.RFC3339))
+			fmt.Fprintf(builder, "| Task execution time | %s |\n", details.ExecutionTime)
+		}
+	}
+
+	if s.ScannerDuration != 0 {
+		fmt.Fprintf(builder, "| Scanner run time | %s |\n", s.ScannerDuration.Round(time.Millisecond))
+	}
+	if s.Status != nil || s.WaitDuration != 0 {
+		fmt.Fprintf(builder, "| Quality gate wait time | %s |\n", s.WaitDuration.Round(time.Millisecond))
+	}
+
+	if s.Error != "" {
+		fmt.Fprintln(builder)
+		fmt.Fprintln(builder, "### Error")
+		fmt.Fprintln(builder)
+		writeCodeBlock(builder, s.Error)
+	}
+
+	if s.Status == nil {
+		return builder.String()
+	}
+
+	if errorMessage := s.Status.TaskDetails.ErrorMessage; errorMessage != "" {
+		fmt.Fprintln(builder)
+		fmt.Fprintln(builder, "### Task error")
+		fmt.Fprintln(builder)
+		writeCodeBlock(builder, errorMessage)
+	}
+
+	if warnings := s.Status.TaskDetails.Warnings; len(warnings) != 0 {
+		fmt.Fprintln(builder)
+		fmt.Fprintln(builder, "### Task warnings")
+		fmt.Fprintln(builder)
+		for _, warning := range warnings {
+			fmt.Fprintf(builder, "- %s\n", escapeTableCell(warning))
+		}
+	}
+
+	failedConditions := s.Status.QualityGateReport.FailedConditions()
+	if len(failedConditions) != 0 {
+		fmt.Fprintln(builder)
+		fmt.Fprintln(builder, "### Failed conditions")
+		fmt.Fprintln(builder)
+		fmt.Fprintln(builder, "| Metric | Comparator | Threshold | Actual | Status |")
+		fmt.Fprintln(builder, "|---|---|---|---|---|")
+		for _, condition := range failedConditions {
+			fmt.Fprintf(
+				builder,
+				"| %s | %s | %s | %s | %s |\n",
+				escapeTableCell(condition.MetricKey),
+				escapeTableCell(condition.Comparator),
+				escapeTableCell(condition.ErrorThreshold),
+				escapeTableCell(condition.ActualValue),
+				condition.Status,
+			)
+		}
+	}
+
+	return builder.String()
+}
+
+// AppendStepSummary appends the markdown to the job summary file, which path
+// GitHub passes in the GITHUB_STEP_SUMMARY environment variable.
+func AppendStepSummary(fileName, markdown string) error {
+	return appendToFile(fileName, markdown)
+}
+
+func appendToFile(fileName, content string) error {
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func writeCodeBlock(builder *strings.Builder, value string) {
+	fmt.Fprintln(builder, "```")
+	fmt.Fprintln(builder, strings.ReplaceAll(value, "```", "` ` `"))
+	fmt.Fprintln(builder, "```")
+}
+
+func escapeTableCell(value string) string {
+	return tableCellEscaper.Replace(value)
+}
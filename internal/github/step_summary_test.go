@@ -0,0 +1,176 @@
+package github
+
+import (
+	"io/ioutil"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarscanner"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepSummaryMarkdown(t *testing.T) {
+	summary := &StepSummary{
+		ReportTask: &sonarscanner.ReportTask{
+			CeTaskId:     "AXoTaskId",
+			DashboardUrl: "http://sonarqube.local/dashboard?id=project",
+		},
+		Status: &sonarscanner.ProjectAnalysisStatus{
+			TaskStatus:     sonarscanner.TaskStatusSuccess,
+			AnalysisStatus: sonarscanner.AnalysisStatusError,
+			QualityGateReport: sonarscanner.QualityGateReport{
+				Status: sonarscanner.AnalysisStatusError,
+				Conditions: []sonarscanner.QualityGateCondition{
+					{
+						MetricKey:      "new_coverage",
+						Comparator:     "LT",
+						ErrorThreshold: "85",
+						ActualValue:    "82.5",
+						Status:         sonarscanner.AnalysisStatusError,
+					},
+					{
+						MetricKey:      "new_bugs",
+						Comparator:     "GT",
+						ErrorThreshold: "0",
+						ActualValue:    "0",
+						Status:         sonarscanner.AnalysisStatusOk,
+					},
+				},
+			},
+		},
+		ScannerDuration: 65 * time.Second,
+		WaitDuration:    1500 * time.Millisecond,
+	}
+
+	assert.Equal(t, `## SonarQube analysis
+
+| | |
+|---|---|
+| Quality gate | **ERROR** |
+| Task | `+"`AXoTaskId`"+` |
+| Dashboard | [http://sonarqube.local/dashboard?id=project](http://sonarqube.local/dashboard?id=project) |
+| Task status | SUCCESS |
+| Scanner run time | 1m5s |
+| Quality gate wait time | 1.5s |
+
+### Failed conditions
+
+| Metric | Comparator | Threshold | Actual | Status |
+|---|---|---|---|---|
+| new_coverage | LT | 85 | 82.5 | ERROR |
+`, summary.Markdown())
+}
+
+func TestStepSummaryMarkdownWithTaskDetails(t *testing.T) {
+	summary := &StepSummary{
+		Status: &sonarscanner.ProjectAnalysisStatus{
+			TaskStatus: sonarscanner.TaskStatusFailed,
+			TaskDetails: sonarscanner.TaskDetails{
+				SubmittedAt:   time.Date(2020, 12, 24, 10, 0, 0, 0, time.UTC),
+				ExecutionTime: 5286 * time.Millisecond,
+				ErrorMessage:  "Fail to extract report",
+				Warnings:      []string{"Missing blame information", "Unsupported\nlanguage"},
+			},
+		},
+		ScannerDuration: 10 * time.Second,
+		WaitDuration:    time.Second,
+	}
+
+	assert.Equal(t, `## SonarQube analysis
+
+| | |
+|---|---|
+| Quality gate | **UNDEFINED** |
+| Task status | FAILED |
+| Task submitted at | 2020-12-24T10:00:00Z |
+| Task execution time | 5.286s |
+| Scanner run time | 10s |
+| Quality gate wait time | 1s |
+
+### Task error
+
+`+"```"+`
+Fail to extract report
+`+"```"+`
+
+### Task warnings
+
+- Missing blame information
+- Unsupported language
+`, summary.Markdown())
+}
+
+func TestStepSummaryMarkdownWithoutStatus(t *testing.T) {
+	summary := &StepSummary{ScannerDuration: 10 * time.Second}
+
+	assert.Equal(t, `## SonarQube analysis
+
+| | |
+|---|---|
+| Quality gate | not checked |
+| Scanner run time | 10s |
+`, summary.Markdown())
+}
+
+func TestStepSummaryMarkdownWithError(t *testing.T) {
+	summary := &StepSummary{
+		ReportTask:   &sonarscanner.ReportTask{CeTaskId: "AXoTaskId"},
+		WaitDuration: 2 * time.Minute,
+		Error:        "quality gate wait timeout",
+	}
+
+	assert.Equal(t, `## SonarQube analysis
+
+| | |
+|---|---|
+| Quality gate | not checked |
+| Task | `+"`AXoTaskId`"+` |
+| Quality gate wait time | 2m0s |
+
+### Error
+
+`+"```"+`
+quality gate wait timeout
+`+"```"+`
+`, summary.Markdown())
+}
+
+func TestStepSummaryMarkdownWithoutScannerRun(t *testing.T) {
+	summary := &StepSummary{
+		ReportTask: &sonarscanner.ReportTask{CeTaskId: "AXoTaskId"},
+		Status: &sonarscanner.ProjectAnalysisStatus{
+			TaskStatus:     sonarscanner.TaskStatusSuccess,
+			AnalysisStatus: sonarscanner.AnalysisStatusOk,
+		},
+		WaitDuration: 2 * time.Second,
+	}
+
+	assert.Equal(t, `## SonarQube analysis
+
+| | |
+|---|---|
+| Quality gate | **OK** |
+| Task | `+"`AXoTaskId`"+` |
+| Task status | SUCCESS |
+| Quality gate wait time | 2s |
+`, summary.Markdown())
+}
+
+func TestAppendStepSummary(t *testing.T) {
+	fileName := path.Join(t.TempDir(), "step-summary.md")
+
+	assert.Nil(t, AppendStepSummary(fileName, "first\n"))
+	assert.Nil(t, AppendStepSummary(fileName, "second\n"))
+
+	content, err := ioutil.ReadFile(fileName)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "first\nsecond\n", string(content))
+}
+
+func TestAppendStepSummaryInvalidPath(t *testing.T) {
+	fileName := path.Join(t.TempDir(), "non-existent", "step-summary.md")
+
+	assert.NotNil(t, AppendStepSummary(fileName, "summary\n"))
+}
@@ -0,0 +1,79 @@
+package github
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/masking"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	errorCommand   = "error"
+	warningCommand = "warning"
+	addMaskCommand = "add-mask"
+)
+
+var dataEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+
+// AnnotationHook is a logrus hook issuing a workflow command for every warning
+// or error logged with the "prefix" field set to Prefix, so that the messages
+// are shown as annotations on the workflow run page. Secrets known to the
+// Masker, if any, are masked in the annotations.
+type AnnotationHook struct {
+	Writer io.Writer
+	Prefix string
+	Masker *masking.Masker
+}
+
+func IssueCommand(writer io.Writer, command, message string) error {
+	_, err := fmt.Fprintf(writer, "::%s::%s\n", command, dataEscaper.Replace(message))
+	return err
+}
+
+func Error(writer io.Writer, message string) error {
+	return IssueCommand(writer, errorCommand, message)
+}
+
+func Warning(writer io.Writer, message string) error {
+	return IssueCommand(writer, warningCommand, message)
+}
+
+// AddMask asks the runner to mask the value in the log. Each line of a multiline
+// value is masked separately, since the runner masks the log line by line.
+func AddMask(writer io.Writer, value string) error {
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		if err := IssueCommand(writer, addMaskCommand, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *AnnotationHook) Levels() []logrus.Level {
+	return []logrus.Level{
+		logrus.PanicLevel,
+		logrus.FatalLevel,
+		logrus.ErrorLevel,
+		logrus.WarnLevel,
+	}
+}
+
+func (h *AnnotationHook) Fire(entry *logrus.Entry) error {
+	if prefix, _ := entry.Data["prefix"].(string); prefix != h.Prefix {
+		return nil
+	}
+
+	message := h.Masker.Mask(entry.Message)
+	if entry.Level == logrus.WarnLevel {
+		return Warning(h.Writer, message)
+	}
+
+	return Error(h.Writer, message)
+}
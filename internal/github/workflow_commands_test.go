@@ -0,0 +1,60 @@
+package github
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/masking"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueCommand(t *testing.T) {
+	buffer := &bytes.Buffer{}
+
+	assert.Nil(t, Error(buffer, "first line\nsecond line 100%"))
+	assert.Nil(t, Warning(buffer, "warning\r\n"))
+
+	assert.Equal(t, "::error::first line%0Asecond line 100%25\n::warning::warning%0D%0A\n", buffer.String())
+}
+
+func TestAddMask(t *testing.T) {
+	buffer := &bytes.Buffer{}
+
+	assert.Nil(t, AddMask(buffer, "secret"))
+	assert.Nil(t, AddMask(buffer, "first line\n\n  second line  \n"))
+
+	assert.Equal(t, "::add-mask::secret\n::add-mask::first line\n::add-mask::second line\n", buffer.String())
+}
+
+func TestAnnotationHookMasksSecrets(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(&AnnotationHook{
+		Writer: buffer,
+		Prefix: "sonar-scanner-cli",
+		Masker: masking.NewMasker("token"),
+	})
+
+	logger.WithField("prefix", "sonar-scanner-cli").Error("invalid token")
+
+	assert.Equal(t, "::error::invalid ***\n", buffer.String())
+}
+
+func TestAnnotationHook(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(&AnnotationHook{Writer: buffer, Prefix: "sonar-scanner-cli"})
+
+	entry := logger.WithField("prefix", "sonar-scanner-cli")
+	entry.Info("info message")
+	entry.Warn("warning message")
+	entry.Error("error message")
+	logger.WithField("prefix", "other").Error("other error message")
+	logger.Error("error message without prefix")
+
+	assert.Equal(t, "::warning::warning message\n::error::error message\n", buffer.String())
+}
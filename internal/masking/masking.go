@@ -0,0 +1,110 @@
+package masking
+
+import (
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const mask = "***"
+
+// Masker replaces the secrets found in a string with asterisks. Besides the
+// secret itself its URL-encoded and base64-encoded forms are replaced, as well
+// as every line of a multiline secret, e.g. a PEM-encoded private key.
+type Masker struct {
+	values   []string
+	replacer *strings.Replacer
+}
+
+// Formatter is a logrus formatter wrapper masking the secrets in the output of
+// the wrapped formatter.
+type Formatter struct {
+	Formatter logrus.Formatter
+	Masker    *Masker
+}
+
+func NewMasker(secrets ...string) *Masker {
+	m := &Masker{}
+	m.Add(secrets...)
+
+	return m
+}
+
+// Add registers more secrets to be masked and returns the forms of them that
+// weren't known to the masker before. Empty secrets are ignored.
+func (m *Masker) Add(secrets ...string) []string {
+	seen := map[string]bool{}
+	for _, value := range m.values {
+		seen[value] = true
+	}
+
+	var added []string
+	for _, secret := range secrets {
+		for _, value := range getSecretForms(secret) {
+			if !seen[value] {
+				seen[value] = true
+				added = append(added, value)
+			}
+		}
+	}
+
+	m.values = append(m.values, added...)
+
+	// Longer values go first, so that a secret containing another one is
+	// masked as a whole.
+	sort.SliceStable(m.values, func(i, j int) bool {
+		return len(m.values[i]) > len(m.values[j])
+	})
+
+	replacements := make([]string, 0, 2*len(m.values))
+	for _, value := range m.values {
+		replacements = append(replacements, value, mask)
+	}
+
+	m.replacer = strings.NewReplacer(replacements...)
+
+	return added
+}
+
+func (m *Masker) Mask(value string) string {
+	if m == nil || m.replacer == nil {
+		return value
+	}
+
+	return m.replacer.Replace(value)
+}
+
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	output, err := f.Formatter.Format(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(f.Masker.Mask(string(output))), nil
+}
+
+func getSecretForms(secret string) []string {
+	var values []string
+	for _, line := range strings.Split(secret, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		values = append(
+			values,
+			line,
+			url.QueryEscape(line),
+			url.PathEscape(line),
+			base64.StdEncoding.EncodeToString([]byte(line)),
+			base64.RawStdEncoding.EncodeToString([]byte(line)),
+			base64.URLEncoding.EncodeToString([]byte(line)),
+			base64.RawURLEncoding.EncodeToString([]byte(line)),
+		)
+	}
+
+	return values
+}
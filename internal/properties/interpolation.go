@@ -0,0 +1,104 @@
+package properties
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const envPrefix = "env."
+
+var placeholderRegex = regexp.MustCompile(`\$\{([\w.\-]+)\}`)
+
+// LookupFunc returns the value of an environment variable and whether it's set,
+// os.LookupEnv being the canonical implementation.
+type LookupFunc func(key string) (string, bool)
+
+type interpolator struct {
+	props     PropertiesMap
+	lookupEnv LookupFunc
+	resolved  PropertiesMap
+}
+
+// Read reads all the properties from the reader into a map. Properties defined
+// more than once take the last value, just like java.util.Properties does.
+func Read(source io.Reader) (PropertiesMap, error) {
+	props := PropertiesMap{}
+	reader := NewReader(source)
+	for reader.Scan() {
+		props[reader.Key()] = reader.Value()
+	}
+
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+
+	return props, nil
+}
+
+// Interpolate returns a copy of the map with every ${env.NAME} placeholder
+// replaced by the value of the NAME environment variable and every ${key}
+// placeholder replaced by the resolved value of the key property. Unknown
+// references resolve to an empty string, the way sonar-scanner does it, and
+// cyclic references result in an error.
+func (props PropertiesMap) Interpolate(lookupEnv LookupFunc) (PropertiesMap, error) {
+	i := &interpolator{
+		props:     props,
+		lookupEnv: lookupEnv,
+		resolved:  PropertiesMap{},
+	}
+
+	for key := range props {
+		if _, err := i.resolve(key, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return i.resolved, nil
+}
+
+func (i *interpolator) resolve(key string, visited []string) (string, error) {
+	if value, ok := i.resolved[key]; ok {
+		return value, nil
+	}
+
+	for index, visitedKey := range visited {
+		if visitedKey == key {
+			cycle := strings.Join(visited[index:], " -> ")
+			return "", fmt.Errorf("cycle detected in properties: %s -> %s", cycle, key)
+		}
+	}
+
+	value, ok := i.props[key]
+	if !ok {
+		return "", nil
+	}
+
+	visited = append(visited, key)
+
+	var err error
+	resolvedValue := placeholderRegex.ReplaceAllStringFunc(value, func(placeholder string) string {
+		if err != nil {
+			return ""
+		}
+
+		reference := placeholderRegex.FindStringSubmatch(placeholder)[1]
+		if strings.HasPrefix(reference, envPrefix) {
+			envValue, _ := i.lookupEnv(strings.TrimPrefix(reference, envPrefix))
+			return envValue
+		}
+
+		var referenceValue string
+		referenceValue, err = i.resolve(reference, visited)
+
+		return referenceValue
+	})
+	if err != nil {
+		return "", err
+	}
+
+	i.resolved[key] = resolvedValue
+
+	return resolvedValue, nil
+}
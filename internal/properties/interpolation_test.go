@@ -0,0 +1,76 @@
+package properties
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	props, err := Read(strings.NewReader("key_1 = value_1\nkey_2 = value_2\nkey_1 = value_3\n"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, PropertiesMap{"key_1": "value_3", "key_2": "value_2"}, props)
+}
+
+func TestReadMalformed(t *testing.T) {
+	props, err := Read(strings.NewReader("key = \\u12"))
+
+	assert.Nil(t, props)
+	assert.NotNil(t, err)
+}
+
+func TestInterpolate(t *testing.T) {
+	props := PropertiesMap{
+		"sonar.host.url": "${env.SONAR_URL}",
+		"sonar.login":    "${login.prefix}-${env.LOGIN_SUFFIX}",
+		"login.prefix":   "${env.LOGIN_PREFIX}",
+		"unknown":        "[${missing}][${env.MISSING}]",
+		"literal":        "$notaplaceholder ${",
+	}
+
+	resolved, err := props.Interpolate(lookupFrom(map[string]string{
+		"SONAR_URL":    "http://sonarqube.local",
+		"LOGIN_PREFIX": "user",
+		"LOGIN_SUFFIX": "name",
+	}))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://sonarqube.local", resolved["sonar.host.url"])
+	assert.Equal(t, "user-name", resolved["sonar.login"])
+	assert.Equal(t, "user", resolved["login.prefix"])
+	assert.Equal(t, "[][]", resolved["unknown"])
+	assert.Equal(t, "$notaplaceholder ${", resolved["literal"])
+	assert.Equal(t, "${env.SONAR_URL}", props["sonar.host.url"])
+}
+
+func TestInterpolateCycle(t *testing.T) {
+	props := PropertiesMap{
+		"key_1": "${key_2}",
+		"key_2": "prefix-${key_3}",
+		"key_3": "${key_1}",
+	}
+
+	resolved, err := props.Interpolate(lookupFrom(nil))
+
+	assert.Nil(t, resolved)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cycle detected")
+}
+
+func TestInterpolateSelfReference(t *testing.T) {
+	props := PropertiesMap{"key": "${key}"}
+
+	resolved, err := props.Interpolate(lookupFrom(nil))
+
+	assert.Nil(t, resolved)
+	assert.NotNil(t, err)
+}
+
+func lookupFrom(env map[string]string) LookupFunc {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
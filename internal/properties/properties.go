@@ -2,38 +2,77 @@ package properties
 
 import (
 	"bufio"
+	"fmt"
 	"io"
-	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
 )
 
-var rx, _ = regexp.Compile("^\\s*([\\w.]+)\\s*=\\s*(.*?)\\s*(#.*)?$")
-
 type PropertiesMap map[string]string
 
+// SyntaxError describes a malformed entry found in a properties file. Line and
+// Column are 1-based and point to the character the error was detected at.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// PropertiesReader reads key-value pairs from a source following the format
+// accepted by java.util.Properties.load: comment lines starting with '#' or
+// '!', backslash line continuations, '=', ':' or whitespace separators and
+// backslash escapes including the \uXXXX ones.
 type PropertiesReader struct {
-	scanner *bufio.Scanner
-	key     string
-	value   string
+	reader *bufio.Reader
+	line   int
+	column int
+	key    string
+	value  string
+	err    error
+}
+
+// char is a single character of a logical line along with its position in the
+// source, used to report errors in terms of the original input.
+type char struct {
+	value  rune
+	line   int
+	column int
 }
 
 func NewReader(reader io.Reader) *PropertiesReader {
 	return &PropertiesReader{
-		scanner: bufio.NewScanner(reader),
+		reader: bufio.NewReader(reader),
+		line:   1,
+		column: 1,
 	}
 }
 
 func (reader *PropertiesReader) Scan() bool {
-	for reader.scanner.Scan() {
-		matches := rx.FindStringSubmatch(reader.scanner.Text())
-		if matches != nil {
-			reader.key = matches[1]
-			reader.value = matches[2]
+	if reader.err != nil {
+		return false
+	}
 
-			return true
-		}
+	line, ok := reader.readLogicalLine()
+	if !ok {
+		return false
+	}
+
+	key, value, err := parseLogicalLine(line)
+	if err != nil {
+		reader.err = err
+		return false
 	}
 
-	return false
+	reader.key = key
+	reader.value = value
+
+	return true
 }
 
 func (reader *PropertiesReader) Key() string {
@@ -45,5 +84,208 @@ func (reader *PropertiesReader) Value() string {
 }
 
 func (reader *PropertiesReader) Err() error {
-	return reader.scanner.Err()
+	return reader.err
+}
+
+// readLogicalLine joins natural lines ending with an odd number of backslashes
+// into a single logical line, skipping blank and comment lines as well as the
+// leading whitespace of every natural line.
+func (reader *PropertiesReader) readLogicalLine() ([]char, bool) {
+	var logicalLine []char
+	continuation := false
+
+	for {
+		naturalLine, eof := reader.readNaturalLine()
+		if reader.err != nil {
+			return nil, false
+		}
+
+		naturalLine = skipWhitespace(naturalLine)
+		if !continuation {
+			if len(naturalLine) == 0 || naturalLine[0].value == '#' || naturalLine[0].value == '!' {
+				if eof {
+					return nil, false
+				}
+
+				continue
+			}
+		}
+
+		continuation = endsWithEscapedLineBreak(naturalLine)
+		if continuation {
+			naturalLine = naturalLine[:len(naturalLine)-1]
+		}
+
+		logicalLine = append(logicalLine, naturalLine...)
+		if !continuation || eof {
+			return logicalLine, true
+		}
+	}
+}
+
+// readNaturalLine reads characters up to the next line terminator, which is one
+// of "\n", "\r" or "\r\n". The second return value is true when the end of the
+// input has been reached.
+func (reader *PropertiesReader) readNaturalLine() ([]char, bool) {
+	var line []char
+	for {
+		r, _, err := reader.reader.ReadRune()
+		if err == io.EOF {
+			return line, true
+		} else if err != nil {
+			reader.err = err
+			return nil, true
+		}
+
+		switch r {
+		case '\r':
+			if next, _, err := reader.reader.ReadRune(); err == nil && next != '\n' {
+				reader.reader.UnreadRune()
+			}
+
+			fallthrough
+		case '\n':
+			reader.line++
+			reader.column = 1
+
+			return line, false
+		}
+
+		line = append(line, char{value: r, line: reader.line, column: reader.column})
+		reader.column++
+	}
+}
+
+func parseLogicalLine(line []char) (string, string, error) {
+	keyEnd := len(line)
+	valueStart := len(line)
+	hasSeparator := false
+	precedingBackslash := false
+
+	for i, c := range line {
+		if !precedingBackslash {
+			if c.value == '=' || c.value == ':' {
+				keyEnd = i
+				valueStart = i + 1
+				hasSeparator = true
+				break
+			} else if isWhitespace(c.value) {
+				keyEnd = i
+				valueStart = i + 1
+				break
+			}
+		}
+
+		precedingBackslash = c.value == '\\' && !precedingBackslash
+	}
+
+	for ; valueStart < len(line); valueStart++ {
+		c := line[valueStart].value
+		if !isWhitespace(c) {
+			if hasSeparator || (c != '=' && c != ':') {
+				break
+			}
+
+			hasSeparator = true
+		}
+	}
+
+	key, err := unescape(line[:keyEnd])
+	if err != nil {
+		return "", "", err
+	}
+
+	value, err := unescape(line[valueStart:])
+	if err != nil {
+		return "", "", err
+	}
+
+	return key, value, nil
+}
+
+func unescape(chars []char) (string, error) {
+	builder := strings.Builder{}
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if c.value != '\\' {
+			builder.WriteRune(c.value)
+			continue
+		}
+
+		i++
+		if i == len(chars) {
+			break
+		}
+
+		switch chars[i].value {
+		case 't':
+			builder.WriteRune('\t')
+		case 'n':
+			builder.WriteRune('\n')
+		case 'r':
+			builder.WriteRune('\r')
+		case 'f':
+			builder.WriteRune('\f')
+		case 'u':
+			r, err := parseUnicodeEscape(chars[i+1:])
+			if err != nil {
+				return "", &SyntaxError{Line: c.line, Column: c.column, Msg: err.Error()}
+			}
+
+			i += 4
+			if utf16.IsSurrogate(r) && i+2 < len(chars) && chars[i+1].value == '\\' && chars[i+2].value == 'u' {
+				if low, err := parseUnicodeEscape(chars[i+3:]); err == nil {
+					if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+						r = pair
+						i += 6
+					}
+				}
+			}
+
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune(chars[i].value)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+func parseUnicodeEscape(chars []char) (rune, error) {
+	if len(chars) < 4 {
+		return 0, fmt.Errorf("malformed \\uxxxx encoding")
+	}
+
+	digits := make([]rune, 4)
+	for i := range digits {
+		digits[i] = chars[i].value
+	}
+
+	code, err := strconv.ParseUint(string(digits), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("malformed \\uxxxx encoding")
+	}
+
+	return rune(code), nil
+}
+
+func skipWhitespace(line []char) []char {
+	for len(line) > 0 && isWhitespace(line[0].value) {
+		line = line[1:]
+	}
+
+	return line
+}
+
+func endsWithEscapedLineBreak(line []char) bool {
+	backslashes := 0
+	for i := len(line) - 1; i >= 0 && line[i].value == '\\'; i-- {
+		backslashes++
+	}
+
+	return backslashes%2 == 1
+}
+
+func isWhitespace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\f'
 }
@@ -27,9 +27,80 @@ func TestReaderScan(t *testing.T) {
 	assert.Equal(t, reader.Value(), "value_2")
 	assert.True(t, reader.Scan())
 	assert.Equal(t, reader.Key(), "param_3")
-	assert.Equal(t, reader.Value(), "value_3")
+	assert.Equal(t, reader.Value(), "value_3 # comment")
 	assert.True(t, reader.Scan())
 	assert.Equal(t, reader.Key(), "param_4")
 	assert.Equal(t, reader.Value(), "http://some-url/?arg=q")
 	assert.False(t, reader.Scan())
 }
+
+func TestReaderScanSeparators(t *testing.T) {
+	sourceFile := "key_1 = value_1\nkey_2: value_2\nkey_3 value_3\nkey_4\t \t: value_4\nkey_5\nkey_6==value_6\n"
+
+	reader := NewReader(strings.NewReader(sourceFile))
+
+	assertNextProperty(t, reader, "key_1", "value_1")
+	assertNextProperty(t, reader, "key_2", "value_2")
+	assertNextProperty(t, reader, "key_3", "value_3")
+	assertNextProperty(t, reader, "key_4", "value_4")
+	assertNextProperty(t, reader, "key_5", "")
+	assertNextProperty(t, reader, "key_6", "=value_6")
+	assert.False(t, reader.Scan())
+	assert.Nil(t, reader.Err())
+}
+
+func TestReaderScanComments(t *testing.T) {
+	sourceFile := "# comment\n! comment\n  ! indented comment \\\nkey = value\n"
+
+	reader := NewReader(strings.NewReader(sourceFile))
+
+	assertNextProperty(t, reader, "key", "value")
+	assert.False(t, reader.Scan())
+	assert.Nil(t, reader.Err())
+}
+
+func TestReaderScanLineContinuations(t *testing.T) {
+	sourceFile := "sonar.exclusions = first/**, \\\r\n" +
+		"    second/**, \\\r" +
+		"    third/**\n" +
+		"escaped.backslash = value\\\\\n" +
+		"trailing = backslash\\"
+
+	reader := NewReader(strings.NewReader(sourceFile))
+
+	assertNextProperty(t, reader, "sonar.exclusions", "first/**, second/**, third/**")
+	assertNextProperty(t, reader, "escaped.backslash", "value\\")
+	assertNextProperty(t, reader, "trailing", "backslash")
+	assert.False(t, reader.Scan())
+	assert.Nil(t, reader.Err())
+}
+
+func TestReaderScanEscapes(t *testing.T) {
+	sourceFile := "key\\=with\\:separators\\ = \\tvalue\\n\\u0041\\uD83D\\uDE00\\q\n"
+
+	reader := NewReader(strings.NewReader(sourceFile))
+
+	assertNextProperty(t, reader, "key=with:separators ", "\tvalue\nA\U0001F600q")
+	assert.False(t, reader.Scan())
+	assert.Nil(t, reader.Err())
+}
+
+func TestReaderScanMalformedUnicodeEscape(t *testing.T) {
+	sourceFile := "key = value\n\n  other = \\u00g1\n"
+
+	reader := NewReader(strings.NewReader(sourceFile))
+
+	assertNextProperty(t, reader, "key", "value")
+	assert.False(t, reader.Scan())
+
+	err, ok := reader.Err().(*SyntaxError)
+	assert.True(t, ok)
+	assert.Equal(t, 3, err.Line)
+	assert.Equal(t, 11, err.Column)
+}
+
+func assertNextProperty(t *testing.T, reader *PropertiesReader, expectedKey, expectedValue string) {
+	assert.True(t, reader.Scan())
+	assert.Equal(t, expectedKey, reader.Key())
+	assert.Equal(t, expectedValue, reader.Value())
+}
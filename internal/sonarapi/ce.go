@@ -0,0 +1,56 @@
+package sonarapi
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Task is a Compute Engine task processing an analysis report.
+type Task struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	ComponentKey string `json:"componentKey"`
+	Status       string `json:"status"`
+	AnalysisId   string `json:"analysisId"`
+	// SubmittedAt is formatted like "2006-01-02T15:04:05-0700", see
+	// ParseDateTime.
+	SubmittedAt     string   `json:"submittedAt"`
+	ExecutionTimeMs int64    `json:"executionTimeMs"`
+	ErrorMessage    string   `json:"errorMessage"`
+	ErrorStacktrace string   `json:"errorStacktrace"`
+	Warnings        []string `json:"warnings"`
+	// RetryAfter is the delay the server asked to wait for before the next
+	// request, if any.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// dateTimeLayout is the layout of the date and time values in the Web API
+// responses.
+const dateTimeLayout = "2006-01-02T15:04:05-0700"
+
+type taskResponse struct {
+	Task Task `json:"task"`
+}
+
+// Task returns the Compute Engine task with the given id, including the error
+// stacktrace and the warnings.
+func (c *Client) Task(ctx context.Context, id string) (*Task, error) {
+	var response taskResponse
+	query := url.Values{
+		"id":               {id},
+		"additionalFields": {"stacktrace,warnings"},
+	}
+	header, err := c.getJson(ctx, "api/ce/task", query, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Task.RetryAfter = parseRetryAfter(header.Get("Retry-After"), time.Now())
+	return &response.Task, nil
+}
+
+// ParseDateTime parses a date and time value of a Web API response.
+func ParseDateTime(value string) (time.Time, error) {
+	return time.Parse(dateTimeLayout, value)
+}
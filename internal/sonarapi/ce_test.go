@@ -0,0 +1,86 @@
+package sonarapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientTask(t *testing.T) {
+	var request *http.Request
+	server := newTestServer("/api/ce/task", `
+    {
+        "task": {
+            "id": "AVAn5RKqYwETbXvgas-I",
+            "type": "REPORT",
+            "componentKey": "project",
+            "status": "SUCCESS",
+            "analysisId": "AU-TpxcA-iU5OvuD2FL3",
+            "submittedAt": "2015-10-02T11:32:15+0200",
+            "executionTimeMs": 5286,
+            "errorMessage": "Fail to extract report",
+            "errorStacktrace": "java.lang.IllegalStateException: Fail to extract report",
+            "warnings": ["Missing blame information"]
+        }
+    }
+    `, &request)
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	task, err := client.Task(context.Background(), "AVAn5RKqYwETbXvgas-I")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "AVAn5RKqYwETbXvgas-I", request.URL.Query().Get("id"))
+	assert.Equal(t, "stacktrace,warnings", request.URL.Query().Get("additionalFields"))
+	assert.Equal(t, &Task{
+		Id:              "AVAn5RKqYwETbXvgas-I",
+		Type:            "REPORT",
+		ComponentKey:    "project",
+		Status:          "SUCCESS",
+		AnalysisId:      "AU-TpxcA-iU5OvuD2FL3",
+		SubmittedAt:     "2015-10-02T11:32:15+0200",
+		ExecutionTimeMs: 5286,
+		ErrorMessage:    "Fail to extract report",
+		ErrorStacktrace: "java.lang.IllegalStateException: Fail to extract report",
+		Warnings:        []string{"Missing blame information"},
+	}, task)
+}
+
+func TestClientTaskRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.Header().Set("Retry-After", "7")
+		res.Write([]byte(`{"task": {"status": "PENDING"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	task, err := client.Task(context.Background(), "task-id")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 7*time.Second, task.RetryAfter)
+}
+
+func TestClientTaskMalformedResponse(t *testing.T) {
+	server := newTestServer("/api/ce/task", `{"task": "unknown"}`, nil)
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	_, err := client.Task(context.Background(), "task-id")
+
+	assert.NotNil(t, err)
+}
+
+func TestParseDateTime(t *testing.T) {
+	value, err := ParseDateTime("2015-10-02T11:32:15+0200")
+
+	assert.Nil(t, err)
+	assert.True(t, time.Date(2015, 10, 2, 9, 32, 15, 0, time.UTC).Equal(value))
+
+	_, err = ParseDateTime("2015-10-02")
+	assert.NotNil(t, err)
+}
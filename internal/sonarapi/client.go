@@ -0,0 +1,178 @@
+package sonarapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	jsonContentType = "application/json"
+	htmlContentType = "text/html"
+)
+
+// ClientFactory holds the settings of a SonarQube Web API client.
+type ClientFactory struct {
+	// BaseUrl is the url the SonarQube server is accessible by, the api
+	// endpoint paths are appended to it.
+	BaseUrl string
+	// Login is either a user login or an authentication token, in which case
+	// the Password must be empty.
+	Login    string
+	Password string
+	// HttpClient is used to make the requests, http.DefaultClient is used if
+	// it's nil.
+	HttpClient *http.Client
+	// RetryPolicy determines how the requests failed with a transient error
+	// are retried, they aren't if it's nil.
+	RetryPolicy *RetryPolicy
+	LogEntry    *logrus.Entry
+}
+
+// Client makes requests to the SonarQube Web API.
+type Client struct {
+	baseUrl     *url.URL
+	login       string
+	password    string
+	httpClient  *http.Client
+	retryPolicy *RetryPolicy
+	log         *logrus.Entry
+}
+
+func (f *ClientFactory) NewClient() (*Client, error) {
+	baseUrl, err := url.Parse(f.BaseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sonar host url: %s", err)
+	}
+
+	if baseUrl.Scheme != "http" && baseUrl.Scheme != "https" {
+		return nil, fmt.Errorf("invalid sonar host url: unsupported scheme '%s'", baseUrl.Scheme)
+	}
+
+	httpClient := f.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	log := f.LogEntry
+	if log == nil {
+		log = logrus.NewEntry(logrus.StandardLogger())
+	}
+
+	return &Client{
+		baseUrl:     baseUrl,
+		login:       f.Login,
+		password:    f.Password,
+		httpClient:  httpClient,
+		retryPolicy: f.RetryPolicy,
+		log:         log,
+	}, nil
+}
+
+// apiUrl returns the url of the api endpoint with the given query parameters.
+func (c *Client) apiUrl(endpoint string, query url.Values) string {
+	apiUrl := *c.baseUrl
+	apiUrl.Path = strings.TrimSuffix(apiUrl.Path, "/") + "/" + strings.TrimPrefix(endpoint, "/")
+	apiUrl.RawPath = ""
+	apiUrl.RawQuery = query.Encode()
+
+	return apiUrl.String()
+}
+
+// getJson makes a GET request to the api endpoint and decodes the json
+// response into the value, returning the response headers.
+func (c *Client) getJson(ctx context.Context, endpoint string, query url.Values, value interface{}) (http.Header, error) {
+	body, header, err := c.do(ctx, http.MethodGet, c.apiUrl(endpoint, query), jsonContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(body, value); err != nil {
+		return nil, fmt.Errorf("malformed response json: %s", err)
+	}
+
+	return header, nil
+}
+
+// do makes a request retrying it according to the retry policy and returns
+// the response body, which is expected to be of the given content type.
+func (c *Client) do(ctx context.Context, method, url, contentType string) ([]byte, http.Header, error) {
+	request, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.login != "" {
+		c.log.Debugf("Using basic auth for request %s", url)
+
+		request.SetBasicAuth(c.login, c.password)
+	}
+
+	for attempts := 1; ; attempts++ {
+		response, err := c.httpClient.Do(request)
+		retry := c.retryPolicy.shouldRetry(method, attempts)
+
+		var retryAfter time.Duration
+		if err != nil {
+			if !retry || !isTransientError(ctx) {
+				return nil, nil, err
+			}
+		} else if retry && isTransientStatusCode(response.StatusCode) {
+			err = newError(response.StatusCode, readErrorMessages(response))
+			retryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+			response.Body.Close()
+		} else {
+			defer response.Body.Close()
+
+			body, err := processResponse(response, contentType)
+			return body, response.Header, err
+		}
+
+		delay := c.retryPolicy.nextDelay(attempts, retryAfter)
+		c.log.Warnf(
+			"Request %s %s failed on attempt %d of %d, retrying in %s: %s",
+			method,
+			url,
+			attempts,
+			c.retryPolicy.MaxAttempts,
+			delay,
+			err,
+		)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func processResponse(response *http.Response, contentType string) ([]byte, error) {
+	if response.StatusCode != http.StatusOK {
+		return nil, newError(response.StatusCode, readErrorMessages(response))
+	}
+
+	responseContentType := response.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(responseContentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response content-type '%s': %s", responseContentType, err)
+	}
+
+	if mediaType != contentType {
+		if mediaType == htmlContentType {
+			return nil, newHtmlResponseError(response)
+		}
+
+		return nil, fmt.Errorf("unexpected response content-type '%s'", responseContentType)
+	}
+
+	return ioutil.ReadAll(response.Body)
+}
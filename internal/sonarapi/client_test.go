@@ -0,0 +1,195 @@
+package sonarapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, factory *ClientFactory) *Client {
+	client, err := factory.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+// newTestServer returns a server responding to the requests to the endpoint
+// with the given json and with 404 to the rest, the last request made to the
+// endpoint is stored to the request.
+func newTestServer(endpoint, json string, request **http.Request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != endpoint {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if request != nil {
+			*request = req
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(json))
+	}))
+}
+
+func TestNewClientInvalidUrl(t *testing.T) {
+	_, err := (&ClientFactory{BaseUrl: "localhost:9000"}).NewClient()
+	assert.NotNil(t, err)
+
+	_, err = (&ClientFactory{BaseUrl: "ftp://localhost"}).NewClient()
+	assert.NotNil(t, err)
+}
+
+func TestClientApiUrl(t *testing.T) {
+	client := newTestClient(t, &ClientFactory{BaseUrl: "http://host/"})
+	assert.Equal(t, "http://host/api/url/", client.apiUrl("/api/url/", nil))
+
+	client = newTestClient(t, &ClientFactory{BaseUrl: "http://host"})
+	assert.Equal(t, "http://host/api/url", client.apiUrl("api/url", nil))
+
+	client = newTestClient(t, &ClientFactory{BaseUrl: "http://host/sonar/"})
+	assert.Equal(t, "http://host/sonar/api/url?id=a%26b", client.apiUrl("api/url", url.Values{"id": {"a&b"}}))
+}
+
+func TestClientUsesBasicAuth(t *testing.T) {
+	var request *http.Request
+	server := newTestServer("/api/ce/task", `{"task": {}}`, &request)
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL, Login: "token"})
+	_, err := client.Task(context.Background(), "task-id")
+
+	assert.Nil(t, err)
+	login, password, ok := request.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "token", login)
+	assert.Equal(t, "", password)
+}
+
+func TestClientWithoutCredentials(t *testing.T) {
+	var request *http.Request
+	server := newTestServer("/api/ce/task", `{"task": {}}`, &request)
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	_, err := client.Task(context.Background(), "task-id")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", request.Header.Get("Authorization"))
+}
+
+func TestClientErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusBadRequest)
+		res.Write([]byte(`{"errors": [{"msg": "first"}, {"msg": "second"}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	_, err := client.Task(context.Background(), "task-id")
+
+	assert.Equal(t, &ResponseError{StatusCode: http.StatusBadRequest, Messages: []string{"first", "second"}}, err)
+	assert.EqualError(t, err, "server returned response with code 400: first; second")
+}
+
+func TestProcessResponse(t *testing.T) {
+	response := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"message": "ok"}`)),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+	}
+
+	body, err := processResponse(response, jsonContentType)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"message": "ok"}`, string(body))
+}
+
+func TestProcessResponseWithInvalidStatus(t *testing.T) {
+	response := &http.Response{
+		StatusCode: 400,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"message": "not ok"}`)),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+	}
+
+	_, err := processResponse(response, jsonContentType)
+
+	assert.Equal(t, &ResponseError{StatusCode: 400}, err)
+}
+
+func TestProcessResponseWithNotFoundStatus(t *testing.T) {
+	response := &http.Response{
+		StatusCode: 404,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"errors": [{"msg": "Component key 'p' not found"}]}`)),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+	}
+
+	_, err := processResponse(response, jsonContentType)
+
+	assert.Equal(t, &NotFoundError{ResponseError{StatusCode: 404, Messages: []string{"Component key 'p' not found"}}}, err)
+}
+
+func TestProcessResponseWithContentTypeParameters(t *testing.T) {
+	response := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"message": "ok"}`)),
+		Header:     http.Header{"Content-Type": {"Application/JSON; charset=utf-8"}},
+	}
+
+	body, err := processResponse(response, jsonContentType)
+
+	assert.Nil(t, err)
+	assert.Equal(t, `{"message": "ok"}`, string(body))
+}
+
+func TestProcessResponseWithInvalidContentType(t *testing.T) {
+	response := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader("message: ok")),
+		Header:     http.Header{"Content-Type": {"application/yaml"}},
+	}
+
+	_, err := processResponse(response, jsonContentType)
+
+	assert.EqualError(t, err, "unexpected response content-type 'application/yaml'")
+}
+
+func TestProcessResponseWithMalformedContentType(t *testing.T) {
+	response := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"message": "ok"}`)),
+		Header:     http.Header{"Content-Type": {"application/json; charset"}},
+	}
+
+	_, err := processResponse(response, jsonContentType)
+
+	assert.NotNil(t, err)
+}
+
+func TestClientHtmlLoginPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/login" {
+			http.Redirect(res, req, "/login", http.StatusFound)
+			return
+		}
+
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+		res.Write([]byte("<html><body><form>Sign in</form></body></html>"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	_, err := client.Task(context.Background(), "task-id")
+
+	assert.Equal(t, &HtmlResponseError{Url: server.URL + "/login"}, err)
+	assert.Contains(t, err.Error(), "login page")
+}
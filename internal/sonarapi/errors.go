@@ -0,0 +1,116 @@
+package sonarapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ResponseError is returned for a response with an unexpected status code, it
+// carries the messages listed in the "errors" field of the response body if
+// any.
+type ResponseError struct {
+	StatusCode int
+	Messages   []string
+}
+
+// UnauthorizedError is returned for a 401 response, meaning that the server
+// didn't accept the credentials or that they are required but weren't given.
+type UnauthorizedError struct {
+	ResponseError
+}
+
+// ForbiddenError is returned for a 403 response, meaning that the user the
+// credentials belong to lacks the permissions required by the request.
+type ForbiddenError struct {
+	ResponseError
+}
+
+// NotFoundError is returned for a 404 response, meaning that the requested
+// entity, e.g. a task, an analysis or a project, doesn't exist or isn't visible
+// to the user.
+type NotFoundError struct {
+	ResponseError
+}
+
+// HtmlResponseError is returned when the server responds with an HTML page
+// instead of the API response, which usually means that the request has been
+// redirected to the login page of a single sign-on proxy in front of the
+// server.
+type HtmlResponseError struct {
+	// Url is the url the page was returned from, after all the redirects.
+	Url string
+}
+
+type errorResponse struct {
+	Errors []struct {
+		Msg string `json:"msg"`
+	} `json:"errors"`
+}
+
+// newError returns an error for a response with the status code, one of the
+// UnauthorizedError, ForbiddenError or NotFoundError if the code matches.
+func newError(statusCode int, messages []string) error {
+	e := ResponseError{StatusCode: statusCode, Messages: messages}
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return &UnauthorizedError{e}
+	case http.StatusForbidden:
+		return &ForbiddenError{e}
+	case http.StatusNotFound:
+		return &NotFoundError{e}
+	default:
+		return &e
+	}
+}
+
+func (e *ResponseError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("server returned response with code %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("server returned response with code %d: %s", e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+func newHtmlResponseError(response *http.Response) *HtmlResponseError {
+	e := &HtmlResponseError{}
+	if response.Request != nil {
+		e.Url = response.Request.URL.String()
+	}
+
+	return e
+}
+
+func (e *HtmlResponseError) Error() string {
+	message := "server returned an HTML page instead of the API response"
+	if e.Url != "" {
+		message += fmt.Sprintf(" from %s", e.Url)
+	}
+
+	return message + ", the request may have been redirected to a login page of a proxy in front of the server"
+}
+
+// readErrorMessages reads the error messages from the body of an error
+// response, nothing is returned if the body isn't a SonarQube error response.
+func readErrorMessages(response *http.Response) []string {
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil
+	}
+
+	var errors errorResponse
+	if err := json.Unmarshal(body, &errors); err != nil {
+		return nil
+	}
+
+	var messages []string
+	for _, e := range errors.Errors {
+		if e.Msg != "" {
+			messages = append(messages, e.Msg)
+		}
+	}
+
+	return messages
+}
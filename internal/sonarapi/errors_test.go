@@ -0,0 +1,61 @@
+package sonarapi
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewError(t *testing.T) {
+	messages := []string{"message"}
+
+	assert.Equal(t, &UnauthorizedError{ResponseError{StatusCode: 401, Messages: messages}}, newError(401, messages))
+	assert.Equal(t, &ForbiddenError{ResponseError{StatusCode: 403, Messages: messages}}, newError(403, messages))
+	assert.Equal(t, &NotFoundError{ResponseError{StatusCode: 404, Messages: messages}}, newError(404, messages))
+	assert.Equal(t, &ResponseError{StatusCode: 500, Messages: messages}, newError(500, messages))
+}
+
+func TestErrorMessage(t *testing.T) {
+	assert.EqualError(t, newError(500, nil), "server returned response with code 500")
+	assert.EqualError(
+		t,
+		newError(403, []string{"Insufficient privileges", "Try again"}),
+		"server returned response with code 403: Insufficient privileges; Try again",
+	)
+}
+
+func TestErrorAs(t *testing.T) {
+	var forbidden *ForbiddenError
+	assert.True(t, errors.As(newError(403, nil), &forbidden))
+	assert.Equal(t, 403, forbidden.StatusCode)
+
+	var notFound *NotFoundError
+	assert.False(t, errors.As(newError(403, nil), &notFound))
+}
+
+func TestReadErrorMessages(t *testing.T) {
+	response := &http.Response{
+		Body: ioutil.NopCloser(strings.NewReader(`
+        {
+            "errors": [
+                {"msg": "No activity found for task 'AXo'"},
+                {"msg": ""}
+            ]
+        }
+        `)),
+	}
+
+	assert.Equal(t, []string{"No activity found for task 'AXo'"}, readErrorMessages(response))
+}
+
+func TestReadErrorMessagesFromNonJsonBody(t *testing.T) {
+	response := &http.Response{
+		Body: ioutil.NopCloser(strings.NewReader("<html>Bad Gateway</html>")),
+	}
+
+	assert.Empty(t, readErrorMessages(response))
+}
@@ -0,0 +1,79 @@
+package sonarapi
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// IssueQuery filters the issues returned by SearchIssues. Empty fields don't
+// restrict the search.
+type IssueQuery struct {
+	ComponentKeys []string
+	Branch        string
+	PullRequest   string
+	Resolved      *bool
+	Severities    []string
+	Types         []string
+	Page          int
+	PageSize      int
+}
+
+type Issue struct {
+	Key       string `json:"key"`
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Type      string `json:"type"`
+	Component string `json:"component"`
+	Line      int    `json:"line"`
+	Message   string `json:"message"`
+	Status    string `json:"status"`
+}
+
+// IssuePage is a single page of the issue search results.
+type IssuePage struct {
+	Total    int     `json:"total"`
+	Page     int     `json:"p"`
+	PageSize int     `json:"ps"`
+	Issues   []Issue `json:"issues"`
+}
+
+// SearchIssues returns a page of the issues matching the query.
+func (c *Client) SearchIssues(ctx context.Context, query IssueQuery) (*IssuePage, error) {
+	var page IssuePage
+	if _, err := c.getJson(ctx, "api/issues/search", query.values(), &page); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+func (q IssueQuery) values() url.Values {
+	values := url.Values{}
+	setValue := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	setValue("componentKeys", strings.Join(q.ComponentKeys, ","))
+	setValue("branch", q.Branch)
+	setValue("pullRequest", q.PullRequest)
+	setValue("severities", strings.Join(q.Severities, ","))
+	setValue("types", strings.Join(q.Types, ","))
+
+	if q.Resolved != nil {
+		values.Set("resolved", strconv.FormatBool(*q.Resolved))
+	}
+
+	if q.Page > 0 {
+		values.Set("p", strconv.Itoa(q.Page))
+	}
+
+	if q.PageSize > 0 {
+		values.Set("ps", strconv.Itoa(q.PageSize))
+	}
+
+	return values
+}
@@ -0,0 +1,75 @@
+package sonarapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientSearchIssues(t *testing.T) {
+	var request *http.Request
+	server := newTestServer("/api/issues/search", `
+    {
+        "total": 1,
+        "p": 2,
+        "ps": 50,
+        "issues": [
+            {
+                "key": "issue-key",
+                "rule": "go:S1192",
+                "severity": "MAJOR",
+                "type": "CODE_SMELL",
+                "component": "project:main.go",
+                "line": 42,
+                "message": "Define a constant",
+                "status": "OPEN"
+            }
+        ]
+    }
+    `, &request)
+	defer server.Close()
+
+	resolved := false
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	page, err := client.SearchIssues(context.Background(), IssueQuery{
+		ComponentKeys: []string{"project"},
+		PullRequest:   "42",
+		Resolved:      &resolved,
+		Types:         []string{"BUG", "VULNERABILITY"},
+		Page:          2,
+		PageSize:      50,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]string{
+		"componentKeys": {"project"},
+		"pullRequest":   {"42"},
+		"resolved":      {"false"},
+		"types":         {"BUG,VULNERABILITY"},
+		"p":             {"2"},
+		"ps":            {"50"},
+	}, map[string][]string(request.URL.Query()))
+	assert.Equal(t, &IssuePage{
+		Total:    1,
+		Page:     2,
+		PageSize: 50,
+		Issues: []Issue{
+			{
+				Key:       "issue-key",
+				Rule:      "go:S1192",
+				Severity:  "MAJOR",
+				Type:      "CODE_SMELL",
+				Component: "project:main.go",
+				Line:      42,
+				Message:   "Define a constant",
+				Status:    "OPEN",
+			},
+		},
+	}, page)
+}
+
+func TestEmptyIssueQueryValues(t *testing.T) {
+	assert.Empty(t, IssueQuery{}.values())
+}
@@ -0,0 +1,45 @@
+package sonarapi
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// ComponentMeasures are the measures of a component, e.g. a project.
+type ComponentMeasures struct {
+	Key      string    `json:"key"`
+	Name     string    `json:"name"`
+	Measures []Measure `json:"measures"`
+}
+
+// Measure is the value of a metric. New code metrics carry their value in the
+// Period instead of the Value.
+type Measure struct {
+	Metric string         `json:"metric"`
+	Value  string         `json:"value"`
+	Period *MeasurePeriod `json:"period,omitempty"`
+}
+
+type MeasurePeriod struct {
+	Value string `json:"value"`
+}
+
+type componentMeasuresResponse struct {
+	Component ComponentMeasures `json:"component"`
+}
+
+// Measures returns the values of the metrics with the given keys for the
+// component.
+func (c *Client) Measures(ctx context.Context, component string, metricKeys ...string) (*ComponentMeasures, error) {
+	var response componentMeasuresResponse
+	query := url.Values{
+		"component":  {component},
+		"metricKeys": {strings.Join(metricKeys, ",")},
+	}
+	if _, err := c.getJson(ctx, "api/measures/component", query, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Component, nil
+}
@@ -0,0 +1,41 @@
+package sonarapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientMeasures(t *testing.T) {
+	var request *http.Request
+	server := newTestServer("/api/measures/component", `
+    {
+        "component": {
+            "key": "project",
+            "name": "Project",
+            "measures": [
+                {"metric": "coverage", "value": "82.5"},
+                {"metric": "new_bugs", "period": {"value": "2"}}
+            ]
+        }
+    }
+    `, &request)
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	measures, err := client.Measures(context.Background(), "project", "coverage", "new_bugs")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "project", request.URL.Query().Get("component"))
+	assert.Equal(t, "coverage,new_bugs", request.URL.Query().Get("metricKeys"))
+	assert.Equal(t, &ComponentMeasures{
+		Key:  "project",
+		Name: "Project",
+		Measures: []Measure{
+			{Metric: "coverage", Value: "82.5"},
+			{Metric: "new_bugs", Period: &MeasurePeriod{Value: "2"}},
+		},
+	}, measures)
+}
@@ -0,0 +1,37 @@
+package sonarapi
+
+import (
+	"context"
+	"net/url"
+)
+
+// ProjectStatus is the quality gate status of a project analysis.
+type ProjectStatus struct {
+	Status     string                 `json:"status"`
+	Conditions []QualityGateCondition `json:"conditions"`
+}
+
+// QualityGateCondition is the status of a single quality gate condition.
+type QualityGateCondition struct {
+	Status         string `json:"status"`
+	MetricKey      string `json:"metricKey"`
+	Comparator     string `json:"comparator"`
+	ErrorThreshold string `json:"errorThreshold"`
+	ActualValue    string `json:"actualValue"`
+}
+
+type projectStatusResponse struct {
+	ProjectStatus ProjectStatus `json:"projectStatus"`
+}
+
+// ProjectStatus returns the quality gate status of the analysis with the given
+// id.
+func (c *Client) ProjectStatus(ctx context.Context, analysisId string) (*ProjectStatus, error) {
+	var response projectStatusResponse
+	query := url.Values{"analysisId": {analysisId}}
+	if _, err := c.getJson(ctx, "api/qualitygates/project_status", query, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.ProjectStatus, nil
+}
@@ -0,0 +1,49 @@
+package sonarapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientProjectStatus(t *testing.T) {
+	var request *http.Request
+	server := newTestServer("/api/qualitygates/project_status", `
+    {
+        "projectStatus": {
+            "status": "ERROR",
+            "conditions": [
+                {
+                    "status": "ERROR",
+                    "metricKey": "new_coverage",
+                    "comparator": "LT",
+                    "periodIndex": 1,
+                    "errorThreshold": "85",
+                    "actualValue": "82.5"
+                }
+            ]
+        }
+    }
+    `, &request)
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	status, err := client.ProjectStatus(context.Background(), "analysis-id")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "analysis-id", request.URL.Query().Get("analysisId"))
+	assert.Equal(t, &ProjectStatus{
+		Status: "ERROR",
+		Conditions: []QualityGateCondition{
+			{
+				Status:         "ERROR",
+				MetricKey:      "new_coverage",
+				Comparator:     "LT",
+				ErrorThreshold: "85",
+				ActualValue:    "82.5",
+			},
+		},
+	}, status)
+}
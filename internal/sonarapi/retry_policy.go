@@ -0,0 +1,87 @@
+package sonarapi
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Backoff determines the delay before the next attempt of a request given the
+// number of attempts made so far, which is at least 1.
+type Backoff interface {
+	NextDelay(attempts int) time.Duration
+}
+
+// RetryPolicy determines how the idempotent requests failed with a transient
+// error, i.e. a network error or a 5xx or 429 response, are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts made for a request,
+	// including the first one. A value less than 2 disables the retries.
+	MaxAttempts int
+	Backoff     Backoff
+}
+
+// shouldRetry tells whether a request attempted the given number of times may
+// be retried. A nil policy never retries.
+func (p *RetryPolicy) shouldRetry(method string, attempts int) bool {
+	return p != nil && isIdempotentMethod(method) && attempts < p.MaxAttempts
+}
+
+// nextDelay returns the delay before the next attempt, which is never less
+// than the one the server asked for in the Retry-After header.
+func (p *RetryPolicy) nextDelay(attempts int, retryAfter time.Duration) time.Duration {
+	delay := p.Backoff.NextDelay(attempts)
+	if retryAfter > delay {
+		return retryAfter
+	}
+
+	return delay
+}
+
+// isIdempotentMethod tells whether a request with the given method can be
+// safely repeated.
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTransientStatusCode tells whether a response with the given status code
+// is likely to succeed when the request is repeated.
+func isTransientStatusCode(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// isTransientError tells whether a request failed to produce a response due to
+// a network error rather than the request context being done. Only the context
+// is checked, since the error of a request exceeding the http.Client timeout
+// matches context.DeadlineExceeded as well, while it's worth a retry.
+func isTransientError(ctx context.Context) bool {
+	return ctx.Err() == nil
+}
+
+// parseRetryAfter parses the Retry-After header value, which is either a number
+// of seconds or an HTTP date. Zero is returned for a missing or invalid value.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,188 @@
+package sonarapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBackoff time.Duration
+
+func (b testBackoff) NextDelay(attempts int) time.Duration {
+	return time.Duration(b)
+}
+
+// newFlakyServer returns a server responding with the given status codes to
+// the first requests and with a successful json response to the rest.
+func newFlakyServer(statusCodes ...int) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests <= len(statusCodes) {
+			res.WriteHeader(statusCodes[requests-1])
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`{"task": {"id": "task-id", "status": "SUCCESS"}}`))
+	}))
+
+	return server, &requests
+}
+
+func newRetryTestClient(t *testing.T, server *httptest.Server, maxAttempts int) *Client {
+	return newTestClient(t, &ClientFactory{
+		BaseUrl:     server.URL,
+		HttpClient:  server.Client(),
+		RetryPolicy: &RetryPolicy{MaxAttempts: maxAttempts, Backoff: testBackoff(time.Millisecond)},
+	})
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3}
+
+	assert.True(t, policy.shouldRetry(http.MethodGet, 1))
+	assert.True(t, policy.shouldRetry(http.MethodGet, 2))
+	assert.False(t, policy.shouldRetry(http.MethodGet, 3))
+	assert.False(t, policy.shouldRetry(http.MethodPost, 1))
+
+	var nilPolicy *RetryPolicy
+	assert.False(t, nilPolicy.shouldRetry(http.MethodGet, 1))
+}
+
+func TestRetryPolicyNextDelay(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, Backoff: testBackoff(2 * time.Second)}
+
+	assert.Equal(t, 2*time.Second, policy.nextDelay(1, 0))
+	assert.Equal(t, 2*time.Second, policy.nextDelay(1, time.Second))
+	assert.Equal(t, 5*time.Second, policy.nextDelay(1, 5*time.Second))
+}
+
+func TestIsTransientStatusCode(t *testing.T) {
+	assert.True(t, isTransientStatusCode(http.StatusTooManyRequests))
+	assert.True(t, isTransientStatusCode(http.StatusInternalServerError))
+	assert.True(t, isTransientStatusCode(http.StatusBadGateway))
+	assert.True(t, isTransientStatusCode(http.StatusServiceUnavailable))
+	assert.False(t, isTransientStatusCode(http.StatusOK))
+	assert.False(t, isTransientStatusCode(http.StatusUnauthorized))
+	assert.False(t, isTransientStatusCode(http.StatusNotFound))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2020, 12, 24, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-5", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+}
+
+func TestClientRetriesTransientStatusCodes(t *testing.T) {
+	server, requests := newFlakyServer(http.StatusBadGateway, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	task, err := newRetryTestClient(t, server, 4).Task(context.Background(), "task-id")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SUCCESS", task.Status)
+	assert.Equal(t, 4, *requests)
+}
+
+func TestClientGivesUpAfterMaxAttempts(t *testing.T) {
+	server, requests := newFlakyServer(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	defer server.Close()
+
+	_, err := newRetryTestClient(t, server, 2).Task(context.Background(), "task-id")
+
+	assert.EqualError(t, err, "server returned response with code 502")
+	assert.Equal(t, 2, *requests)
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	server, requests := newFlakyServer(http.StatusNotFound)
+	defer server.Close()
+
+	_, err := newRetryTestClient(t, server, 4).Task(context.Background(), "task-id")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, *requests)
+}
+
+func TestClientDoesNotRetryNonIdempotentRequests(t *testing.T) {
+	server, requests := newFlakyServer(http.StatusBadGateway)
+	defer server.Close()
+
+	client := newRetryTestClient(t, server, 4)
+	_, _, err := client.do(context.Background(), http.MethodPost, server.URL, jsonContentType)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, *requests)
+}
+
+func TestClientRetriesConnectionErrors(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests == 1 {
+			conn, _, _ := res.(http.Hijacker).Hijack()
+			conn.(*net.TCPConn).SetLinger(0)
+			conn.Close()
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`{"task": {"id": "task-id", "status": "SUCCESS"}}`))
+	}))
+	defer server.Close()
+
+	task, err := newRetryTestClient(t, server, 2).Task(context.Background(), "task-id")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SUCCESS", task.Status)
+	assert.Equal(t, 2, requests)
+}
+
+func TestClientRetriesRequestTimeouts(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests++
+		if requests == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`{"task": {"id": "task-id", "status": "SUCCESS"}}`))
+	}))
+	defer server.Close()
+
+	client := newRetryTestClient(t, server, 2)
+	client.httpClient.Timeout = 50 * time.Millisecond
+
+	task, err := client.Task(context.Background(), "task-id")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "SUCCESS", task.Status)
+	assert.Equal(t, 2, requests)
+}
+
+func TestClientStopsRetryingWhenContextIsDone(t *testing.T) {
+	server, _ := newFlakyServer(http.StatusBadGateway, http.StatusBadGateway)
+	defer server.Close()
+
+	client := newRetryTestClient(t, server, 3)
+	client.retryPolicy.Backoff = testBackoff(time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Task(ctx, "task-id")
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
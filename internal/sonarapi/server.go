@@ -0,0 +1,19 @@
+package sonarapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const textContentType = "text/plain"
+
+// ServerVersion returns the version of the SonarQube server.
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	body, _, err := c.do(ctx, http.MethodGet, c.apiUrl("api/server/version", nil), textContentType)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,26 @@
+package sonarapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientServerVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/api/server/version", req.URL.Path)
+
+		res.Header().Set("Content-Type", "text/plain;charset=utf-8")
+		res.Write([]byte("8.6.0.39681\n"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, &ClientFactory{BaseUrl: server.URL})
+	version, err := client.ServerVersion(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "8.6.0.39681", version)
+}
@@ -0,0 +1,32 @@
+package sonarscanner
+
+import "fmt"
+
+// BranchParameters describe the branch or the pull request the analysis is run
+// for. Either BranchName or the pull request fields are expected to be set.
+type BranchParameters struct {
+	BranchName        string
+	PullRequestKey    string
+	PullRequestBranch string
+	PullRequestBase   string
+}
+
+func (p *BranchParameters) args() []string {
+	if p.PullRequestKey != "" {
+		args := []string{
+			fmt.Sprintf("-Dsonar.pullrequest.key=%s", p.PullRequestKey),
+			fmt.Sprintf("-Dsonar.pullrequest.branch=%s", p.PullRequestBranch),
+		}
+		if p.PullRequestBase != "" {
+			args = append(args, fmt.Sprintf("-Dsonar.pullrequest.base=%s", p.PullRequestBase))
+		}
+
+		return args
+	}
+
+	if p.BranchName != "" {
+		return []string{fmt.Sprintf("-Dsonar.branch.name=%s", p.BranchName)}
+	}
+
+	return nil
+}
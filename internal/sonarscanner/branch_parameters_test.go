@@ -0,0 +1,33 @@
+package sonarscanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchParametersArgs(t *testing.T) {
+	params := &BranchParameters{BranchName: "feature/branch"}
+
+	assert.Equal(t, []string{"-Dsonar.branch.name=feature/branch"}, params.args())
+}
+
+func TestPullRequestBranchParametersArgs(t *testing.T) {
+	params := &BranchParameters{
+		PullRequestKey:    "42",
+		PullRequestBranch: "feature/branch",
+		PullRequestBase:   "main",
+	}
+
+	assert.Equal(t, []string{
+		"-Dsonar.pullrequest.key=42",
+		"-Dsonar.pullrequest.branch=feature/branch",
+		"-Dsonar.pullrequest.base=main",
+	}, params.args())
+}
+
+func TestEmptyBranchParametersArgs(t *testing.T) {
+	params := &BranchParameters{}
+
+	assert.Empty(t, params.args())
+}
@@ -0,0 +1,128 @@
+package sonarscanner
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/youmark/pkcs8"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// loadClientCertificate loads the TLS client certificate either from the PEM
+// encoded certificate chain and private key or, when the certificate isn't PEM
+// encoded, from the base64-encoded PKCS#12 bundle. The password is used to
+// decrypt the encrypted PKCS#8 or legacy encrypted PEM private key, or the
+// PKCS#12 bundle.
+func loadClientCertificate(log *logrus.Entry, cert, key, password string) (*tls.Certificate, error) {
+	if !strings.Contains(cert, "-----BEGIN") {
+		if key != "" {
+			log.Warn("Client certificate private key is ignored, the key is taken from the PKCS#12 bundle")
+		}
+
+		return loadPkcs12ClientCertificate(cert, password)
+	}
+
+	if key == "" {
+		return nil, errors.New("client certificate private key is required")
+	}
+
+	keyPem, err := decryptPrivateKey(log, []byte(key), password)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := tls.X509KeyPair([]byte(cert), keyPem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the client certificate: %s", err)
+	}
+
+	return &certificate, nil
+}
+
+func loadPkcs12ClientCertificate(bundle, password string) (*tls.Certificate, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(bundle), ""))
+	if err != nil {
+		return nil, fmt.Errorf("client certificate is neither PEM nor base64-encoded PKCS#12: %s", err)
+	}
+
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the PKCS#12 client certificate: %s", err)
+	}
+
+	var certPem, keyPem []byte
+	for _, block := range blocks {
+		if block.Type == "CERTIFICATE" {
+			certPem = append(certPem, pem.EncodeToMemory(block)...)
+		} else {
+			keyPem = append(keyPem, pem.EncodeToMemory(block)...)
+		}
+	}
+
+	certificate, err := tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the PKCS#12 client certificate: %s", err)
+	}
+
+	return &certificate, nil
+}
+
+func decryptPrivateKey(log *logrus.Entry, key []byte, password string) ([]byte, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("client certificate private key is not PEM-encoded")
+	}
+
+	encryptedPkcs8 := block.Type == "ENCRYPTED PRIVATE KEY"
+	if !encryptedPkcs8 && !x509.IsEncryptedPEMBlock(block) {
+		return key, nil
+	}
+
+	if password == "" {
+		return nil, errors.New("client certificate private key is encrypted but no password given")
+	}
+
+	if encryptedPkcs8 {
+		return decryptPkcs8PrivateKey(block, password)
+	}
+
+	// The legacy PEM encryption is insecure and deprecated, yet it's still
+	// produced by "openssl rsa -des3", so it's supported for the compatibility.
+	log.Warn(
+		"Client certificate private key uses the insecure legacy PEM encryption, " +
+			"convert it to the encrypted PKCS#8 with 'openssl pkcs8 -topk8 -v2 aes-256-cbc'",
+	)
+
+	der, err := x509.DecryptPEMBlock(block, []byte(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the client certificate private key: %s", err)
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := pem.Encode(buffer, &pem.Block{Type: block.Type, Bytes: der}); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func decryptPkcs8PrivateKey(block *pem.Block, password string) ([]byte, error) {
+	privateKey, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the client certificate private key: %s", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,231 @@
+package sonarscanner
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/youmark/pkcs8"
+)
+
+// testPkcs12Bundle is a base64-encoded PKCS#12 bundle protected with the
+// "secret" password, containing a self-signed certificate and its key.
+const testPkcs12Bundle = "" +
+	"MIIDogIBAzCCA2gGCSqGSIb3DQEHAaCCA1kEggNVMIIDUTCCAkcGCSqGSIb3DQEHBqCCAjgw" +
+	"ggI0AgEAMIICLQYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQMwDgQIqY1yWGDd3DQCAggAgIIC" +
+	"AGoHP7jmClruKaKD1dGQhOtBmW/1tt3GEi4BeDCc9wE/DONKsRJ9YHPaaKYNHYS0ebFZWZWo" +
+	"PT7Dme4zZ2NhKOz8XLmhAG4U/1/jLGQsXQu5hOXTCR/dpgiD7BcSA+dm213Lu4uaLAl5LyuY" +
+	"MLpz2VkrPMqiqD7DjtDftLizoD/rC94eEmiGiqiYPTDS9202al6jU8Cks1HiuXBD2NBNN7UH" +
+	"7+P5cTBK06tPilUzlAt+BgIFBscaifs7wTlj+QOqTNHy7Rivw8Z4lPtq0pe3cwBMuqSt/v1g" +
+	"5soSK2nAIn1m7rViMYYNCHRinPi9XcB0HawHv1riaKnsq//QNoTGy/cC5/DFOGhELQxfGqUk" +
+	"/Ua9c671vvmgL16B7k831qWaM34UmRb94G9l4j7IsAO0n4irgPVS6n1bDWtRcOwU0E3c+2W/" +
+	"b3j3tFWuvZNJib13Q4xg0PHNPYiXwnEoDnY8QfOfphQXffmVn+BsrTVHIKJ2VwqVEbW+9mFT" +
+	"7NHeqvkVo8fu3jrMrccD+JO71NgwCx4s09ekEsA6BBL9v3/RWzzNF8xdsFoqHDxMQgazBzy4" +
+	"igPjr915Ryipichk/CKsFLC4sT93nDxFhnhezttexeoVC93muWmIyELMqqT3Y8fIgErcs7Kh" +
+	"QAVoIPpTU8Xo3lN4CvanS2yJTzLicq9HQ8HnMIIBAgYJKoZIhvcNAQcBoIH0BIHxMIHuMIHr" +
+	"BgsqhkiG9w0BDAoBAqCBtDCBsTAcBgoqhkiG9w0BDAEDMA4ECBuLjMDAtPExAgIIAASBkJlG" +
+	"daAISIiQmYuLPsqqdoazFnfp624RhoLATfgRxuDXGCLLTqXlcAvRm4p1G7nK6phv6NXkqM3n" +
+	"qLi7ilhlpwmxWsdZCcn5a/EWut+zRHMsrUv0Uy7N2ctHxb45Gs18IgAWi8Z2e6zOgg/ywQQR" +
+	"XMGYpw4ySQ8aH11xYTzpYrwwJej+QSOXeRlkzdMoik3FjjElMCMGCSqGSIb3DQEJFTEWBBS9" +
+	"7Iu/gJIh6h5qBIIYQOiR07A5pTAxMCEwCQYFKw4DAhoFAAQUsai4xzpr6Cdyk3AKR62LYG6r" +
+	"anMECNwad4UD8/JEAgIIAA=="
+
+func TestLoadClientCertificate(t *testing.T) {
+	cert, key := generateTestCertificate(t)
+
+	certificate, err := loadClientCertificate(testLogEntry(), cert, key, "")
+
+	assert.Nil(t, err)
+	assert.NotNil(t, certificate)
+	assert.Len(t, certificate.Certificate, 1)
+}
+
+func TestLoadClientCertificateEncryptedKey(t *testing.T) {
+	cert, key := generateTestCertificate(t)
+	block, _ := pem.Decode([]byte(key))
+	encryptedBlock, err := x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte("secret"), x509.PEMCipherAES256)
+	assert.Nil(t, err)
+
+	encryptedKey := string(pem.EncodeToMemory(encryptedBlock))
+
+	certificate, err := loadClientCertificate(testLogEntry(), cert, encryptedKey, "secret")
+
+	assert.Nil(t, err)
+	assert.NotNil(t, certificate)
+
+	certificate, err = loadClientCertificate(testLogEntry(), cert, encryptedKey, "")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, certificate)
+
+	certificate, err = loadClientCertificate(testLogEntry(), cert, encryptedKey, "wrong")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, certificate)
+}
+
+func TestLoadClientCertificateWithoutKey(t *testing.T) {
+	cert, _ := generateTestCertificate(t)
+
+	certificate, err := loadClientCertificate(testLogEntry(), cert, "", "")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, certificate)
+}
+
+func TestLoadClientCertificateMismatchingKey(t *testing.T) {
+	cert, _ := generateTestCertificate(t)
+	_, key := generateTestCertificate(t)
+
+	certificate, err := loadClientCertificate(testLogEntry(), cert, key, "")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, certificate)
+}
+
+func TestLoadClientCertificateEncryptedPkcs8Key(t *testing.T) {
+	cert, key := generateTestCertificate(t)
+	block, _ := pem.Decode([]byte(key))
+	privateKey, err := x509.ParseECPrivateKey(block.Bytes)
+	assert.Nil(t, err)
+
+	der, err := pkcs8.MarshalPrivateKey(privateKey, []byte("secret"), nil)
+	assert.Nil(t, err)
+
+	encryptedKey := string(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der}))
+
+	certificate, err := loadClientCertificate(testLogEntry(), cert, encryptedKey, "secret")
+
+	assert.Nil(t, err)
+	assert.NotNil(t, certificate)
+
+	certificate, err = loadClientCertificate(testLogEntry(), cert, encryptedKey, "")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, certificate)
+
+	certificate, err = loadClientCertificate(testLogEntry(), cert, encryptedKey, "wrong")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, certificate)
+}
+
+func TestLoadClientCertificatePkcs12(t *testing.T) {
+	certificate, err := loadClientCertificate(testLogEntry(), testPkcs12Bundle, "", "secret")
+
+	assert.Nil(t, err)
+	assert.NotNil(t, certificate)
+
+	certificate, err = loadClientCertificate(testLogEntry(), testPkcs12Bundle, "", "wrong")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, certificate)
+
+	certificate, err = loadClientCertificate(testLogEntry(), "not a base64 bundle", "", "secret")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, certificate)
+}
+
+func TestLoadClientCertificatePkcs12IgnoresKey(t *testing.T) {
+	_, key := generateTestCertificate(t)
+	buffer := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.Out = buffer
+
+	certificate, err := loadClientCertificate(logrus.NewEntry(logger), testPkcs12Bundle, key, "secret")
+
+	assert.Nil(t, err)
+	assert.NotNil(t, certificate)
+	assert.Contains(t, buffer.String(), "Client certificate private key is ignored")
+}
+
+func TestSonarHostProxyWithClientCertificate(t *testing.T) {
+	cert, key := generateTestCertificate(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(cert))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(req.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	runFactory := &RunFactory{
+		SonarHostUrl:    server.URL,
+		SonarHostCert:   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+		SonarClientCert: cert,
+		SonarClientKey:  key,
+		LogEntry:        logrus.NewEntry(logrus.New()),
+	}
+	tlsConfig, err := runFactory.getTlsClientConfig()
+	assert.Nil(t, err)
+
+	proxyFactory := &sonarHostProxyFactory{
+		listenAddr:   "localhost:0",
+		sonarHostUrl: server.URL,
+		config:       tlsConfig,
+		log:          logrus.NewEntry(logrus.New()),
+	}
+	proxy, err := proxyFactory.new()
+	assert.Nil(t, err)
+
+	recorder := httptest.NewRecorder()
+	proxy.proxy.ServeHTTP(recorder, httptest.NewRequest("GET", "http://localhost/api/server/version", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "sonar-scanner-action-client", recorder.Body.String())
+}
+
+func generateTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sonar-scanner-action-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	return string(cert), string(keyPem)
+}
+
+func testLogEntry() *logrus.Entry {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	return logrus.NewEntry(logger)
+}
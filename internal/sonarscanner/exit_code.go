@@ -0,0 +1,92 @@
+package sonarscanner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarapi"
+)
+
+// Exit codes of the action, each one of them stands for a failure category.
+const (
+	// ExitCodeSuccess means that the analysis succeeded and passed the
+	// quality gate according to the failure policy, if it was checked.
+	ExitCodeSuccess = 0
+	// ExitCodeFailure means an unexpected failure not covered by the other
+	// codes.
+	ExitCodeFailure = 1
+	// ExitCodeConfigError means that the action is misconfigured.
+	ExitCodeConfigError = 2
+	// ExitCodeProxyError means that the sonar host proxy failed to start.
+	ExitCodeProxyError = 3
+	// ExitCodeScannerError means that sonar-scanner failed to start, exited
+	// with a non-zero code or didn't submit the analysis report.
+	ExitCodeScannerError = 4
+	// ExitCodeTaskFailed means that the analysis task failed or was
+	// cancelled.
+	ExitCodeTaskFailed = 5
+	// ExitCodeQualityGateFailed means that the quality gate failed according
+	// to the failure policy.
+	ExitCodeQualityGateFailed = 6
+	// ExitCodeWaitTimeout means that the analysis task or the quality gate
+	// status wasn't retrieved in time, or that the analysis task hadn't
+	// finished when checked without waiting.
+	ExitCodeWaitTimeout = 7
+	// ExitCodeAuthError means that SonarQube rejected the credentials or they
+	// lack the required permissions.
+	ExitCodeAuthError = 8
+)
+
+// ProxyError is returned by RunScanner when the sonar host proxy fails to
+// start listening on its address.
+type ProxyError struct {
+	Err error
+}
+
+// ScannerError is returned by RunScanner when sonar-scanner fails to start,
+// exits with a non-zero code or doesn't write the analysis report metadata.
+type ScannerError struct {
+	Err error
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("failed to start a sonar host proxy: %s", e.Err)
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ScannerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ScannerError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of the action corresponding to the category
+// of an error returned by a Run.
+func ExitCode(err error) int {
+	var proxyError *ProxyError
+	var scannerError *ScannerError
+	var unauthorizedError *sonarapi.UnauthorizedError
+	var forbiddenError *sonarapi.ForbiddenError
+
+	switch {
+	case err == nil:
+		return ExitCodeSuccess
+	case errors.As(err, &proxyError):
+		return ExitCodeProxyError
+	case errors.As(err, &scannerError):
+		return ExitCodeScannerError
+	case errors.Is(err, QualityGateWaitTimeout),
+		errors.Is(err, AnalysisStatusWaitTimeout),
+		errors.Is(err, AnalysisTaskNotFinished):
+		return ExitCodeWaitTimeout
+	case errors.As(err, &unauthorizedError), errors.As(err, &forbiddenError):
+		return ExitCodeAuthError
+	default:
+		return ExitCodeFailure
+	}
+}
@@ -0,0 +1,58 @@
+package sonarscanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, ExitCodeSuccess, ExitCode(nil))
+	assert.Equal(t, ExitCodeFailure, ExitCode(errors.New("unexpected")))
+	assert.Equal(t, ExitCodeProxyError, ExitCode(&ProxyError{Err: errors.New("address in use")}))
+	assert.Equal(t, ExitCodeScannerError, ExitCode(&ScannerError{Err: &exec.ExitError{}}))
+	assert.Equal(t, ExitCodeWaitTimeout, ExitCode(QualityGateWaitTimeout))
+	assert.Equal(t, ExitCodeWaitTimeout, ExitCode(AnalysisStatusWaitTimeout))
+	assert.Equal(t, ExitCodeWaitTimeout, ExitCode(AnalysisTaskNotFinished))
+	assert.Equal(t, ExitCodeFailure, ExitCode(&url.Error{Op: "Get", URL: "/", Err: context.DeadlineExceeded}))
+}
+
+func TestExitCodeOfApiErrors(t *testing.T) {
+	assert.Equal(t, ExitCodeAuthError, ExitCode(requestTaskWithStatusCode(t, 401)))
+	assert.Equal(t, ExitCodeAuthError, ExitCode(fmt.Errorf("wrapped: %w", requestTaskWithStatusCode(t, 403))))
+	assert.Equal(t, ExitCodeFailure, ExitCode(requestTaskWithStatusCode(t, 404)))
+}
+
+func TestProxyError(t *testing.T) {
+	cause := errors.New("address in use")
+	err := &ProxyError{Err: cause}
+
+	assert.EqualError(t, err, "failed to start a sonar host proxy: address in use")
+	assert.True(t, errors.Is(err, cause))
+}
+
+// requestTaskWithStatusCode returns the error of a task request to a server
+// responding with the status code.
+func requestTaskWithStatusCode(t *testing.T, statusCode int) error {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+
+	run := &Run{sonarHostUrl: server.URL, log: logrus.NewEntry(logrus.New())}
+	client, err := run.newApiClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Task(context.Background(), "task-id")
+	return err
+}
@@ -0,0 +1,132 @@
+package sonarscanner
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// reservedProperties are the sonar-scanner properties controlled by the action
+// itself, which can't be overridden with the extra arguments.
+var reservedProperties = []string{
+	"sonar.host.url",
+	"sonar.working.directory",
+	"sonar.scanner.metadataFilePath",
+}
+
+// credentialProperties are the sonar-scanner properties holding credentials,
+// which must never appear on the sonar-scanner command line. The sonar host
+// proxy adds the configured credentials to the requests instead.
+var credentialProperties = []string{
+	"sonar.login",
+	"sonar.password",
+	"sonar.token",
+}
+
+// parseExtraArgs splits the string into arguments the way a POSIX shell does,
+// supporting single quotes, double quotes and backslash escapes but no
+// expansions, and makes sure none of them overrides a reserved property.
+func parseExtraArgs(value string) ([]string, error) {
+	args, err := splitArgs(value)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, arg := range args {
+		var property string
+		switch {
+		case arg == "-D" || arg == "--define":
+			if i+1 < len(args) {
+				property = args[i+1]
+			}
+		case strings.HasPrefix(arg, "--define="):
+			property = strings.TrimPrefix(arg, "--define=")
+		case strings.HasPrefix(arg, "-D"):
+			property = strings.TrimPrefix(arg, "-D")
+		default:
+			continue
+		}
+
+		key := strings.TrimSpace(strings.SplitN(property, "=", 2)[0])
+		for _, reservedProperty := range reservedProperties {
+			if key == reservedProperty {
+				return nil, fmt.Errorf("property '%s' can't be overridden by the extra arguments", key)
+			}
+		}
+
+		for _, credentialProperty := range credentialProperties {
+			if key == credentialProperty {
+				return nil, fmt.Errorf(
+					"property '%s' can't be set by the extra arguments, use the sonar-login and sonar-password inputs instead",
+					key,
+				)
+			}
+		}
+	}
+
+	return args, nil
+}
+
+func splitArgs(value string) ([]string, error) {
+	var args []string
+	var quote rune
+
+	arg := strings.Builder{}
+	inArg := false
+	escaped := false
+
+	for _, c := range value {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\\\"$`", c) {
+				arg.WriteRune('\\')
+			}
+			if c != '\n' {
+				arg.WriteRune(c)
+			}
+
+			escaped = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(c)
+			}
+		case c == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inArg = true
+		case unicode.IsSpace(c):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(c)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("unexpected end of the arguments after a backslash")
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in the arguments", quote)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,71 @@
+package sonarscanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitArgs(t *testing.T) {
+	assertArgsSplitAs(t, "", nil)
+	assertArgsSplitAs(t, "  \t\n ", nil)
+	assertArgsSplitAs(t, "-X -Dsonar.projectVersion=1.0", []string{"-X", "-Dsonar.projectVersion=1.0"})
+	assertArgsSplitAs(t, "-Dsonar.sources='src main'", []string{"-Dsonar.sources=src main"})
+	assertArgsSplitAs(t, `-Dsonar.projectName="My \"Project\" \n"`, []string{`-Dsonar.projectName=My "Project" \n`})
+	assertArgsSplitAs(t, `a\ b 'c\d' "" ''`, []string{"a b", `c\d`, "", ""})
+	assertArgsSplitAs(t, "first \\\nsecond", []string{"first", "second"})
+}
+
+func TestSplitArgsMalformed(t *testing.T) {
+	for _, value := range []string{"'unterminated", `"unterminated`, `trailing\`} {
+		args, err := splitArgs(value)
+
+		assert.NotNil(t, err, value)
+		assert.Nil(t, args, value)
+	}
+}
+
+func TestParseExtraArgs(t *testing.T) {
+	args, err := parseExtraArgs("-Dsonar.projectVersion=1.0 --define sonar.sources=src -X")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"-Dsonar.projectVersion=1.0", "--define", "sonar.sources=src", "-X"}, args)
+}
+
+func TestParseExtraArgsReservedProperties(t *testing.T) {
+	for _, value := range []string{
+		"-Dsonar.host.url=http://localhost",
+		"'-Dsonar.working.directory=/tmp'",
+		"-D sonar.host.url=http://localhost",
+		"--define sonar.scanner.metadataFilePath=/tmp/report-task.txt",
+		"--define=sonar.host.url=http://localhost",
+	} {
+		args, err := parseExtraArgs(value)
+
+		assert.NotNil(t, err, value)
+		assert.Nil(t, args, value)
+	}
+}
+
+func TestParseExtraArgsCredentialProperties(t *testing.T) {
+	for _, value := range []string{
+		"-Dsonar.login=admin",
+		"-Dsonar.password=secret",
+		"-Dsonar.token=squ_token",
+		"--define sonar.token=squ_token",
+		"-D 'sonar.login = admin'",
+	} {
+		args, err := parseExtraArgs(value)
+
+		assert.NotNil(t, err, value)
+		assert.Contains(t, err.Error(), "sonar-login", value)
+		assert.Nil(t, args, value)
+	}
+}
+
+func assertArgsSplitAs(t *testing.T, value string, expectedArgs []string) {
+	args, err := splitArgs(value)
+
+	assert.Nil(t, err)
+	assert.Equal(t, expectedArgs, args)
+}
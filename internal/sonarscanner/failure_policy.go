@@ -0,0 +1,87 @@
+package sonarscanner
+
+import "fmt"
+
+// FailurePolicy determines which quality gate statuses fail the run. Each
+// policy fails on the statuses the previous ones do, except for the
+// FailurePolicyNever one, which doesn't fail on any quality gate status.
+type FailurePolicy int
+
+const (
+	// FailurePolicyError fails the run if the quality gate status is ERROR.
+	FailurePolicyError FailurePolicy = iota
+	// FailurePolicyWarning fails the run if the quality gate status is WARN as
+	// well.
+	FailurePolicyWarning FailurePolicy = iota
+	// FailurePolicyNone fails the run if there is no quality gate set for the
+	// project as well, i.e. the status is NONE.
+	FailurePolicyNone FailurePolicy = iota
+	// FailurePolicyNever only reports the quality gate status.
+	FailurePolicyNever FailurePolicy = iota
+)
+
+const (
+	failurePolicyErrorStr   = "error"
+	failurePolicyWarningStr = "warn"
+	failurePolicyNoneStr    = "none"
+	failurePolicyNeverStr   = "never"
+)
+
+func (policy FailurePolicy) String() string {
+	switch policy {
+	case FailurePolicyWarning:
+		return failurePolicyWarningStr
+	case FailurePolicyNone:
+		return failurePolicyNoneStr
+	case FailurePolicyNever:
+		return failurePolicyNeverStr
+	default:
+		return failurePolicyErrorStr
+	}
+}
+
+func ParseFailurePolicy(value string) (FailurePolicy, error) {
+	switch value {
+	case failurePolicyErrorStr:
+		return FailurePolicyError, nil
+	case failurePolicyWarningStr:
+		return FailurePolicyWarning, nil
+	case failurePolicyNoneStr:
+		return FailurePolicyNone, nil
+	case failurePolicyNeverStr:
+		return FailurePolicyNever, nil
+	default:
+		return FailurePolicyError, fmt.Errorf("unknown failure policy '%s'", value)
+	}
+}
+
+// Evaluate decides whether the run has failed given the project analysis
+// status and returns the exit code of the action along with the reason of the
+// failure, if any. A task that hasn't succeeded fails the run regardless of the
+// policy.
+func (policy FailurePolicy) Evaluate(status ProjectAnalysisStatus) (int, error) {
+	if status.TaskStatus != TaskStatusSuccess {
+		return ExitCodeTaskFailed, fmt.Errorf("analysis task failed with the status '%s'", status.TaskStatus)
+	}
+
+	if policy.failsOn(status.AnalysisStatus) {
+		return ExitCodeQualityGateFailed, fmt.Errorf("quality gate failed with the status '%s'", status.AnalysisStatus)
+	}
+
+	return ExitCodeSuccess, nil
+}
+
+func (policy FailurePolicy) failsOn(status AnalysisStatus) bool {
+	switch status {
+	case AnalysisStatusOk:
+		return false
+	case AnalysisStatusError:
+		return policy != FailurePolicyNever
+	case AnalysisStatusWarning:
+		return policy == FailurePolicyWarning || policy == FailurePolicyNone
+	case AnalysisStatusNone:
+		return policy == FailurePolicyNone
+	default:
+		return policy != FailurePolicyNever
+	}
+}
@@ -0,0 +1,101 @@
+package sonarscanner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailurePolicyToString(t *testing.T) {
+	assert.Equal(t, "error", fmt.Sprint(FailurePolicyError))
+	assert.Equal(t, "warn", fmt.Sprint(FailurePolicyWarning))
+	assert.Equal(t, "none", fmt.Sprint(FailurePolicyNone))
+	assert.Equal(t, "never", fmt.Sprint(FailurePolicyNever))
+}
+
+func TestParseFailurePolicy(t *testing.T) {
+	for _, policy := range []FailurePolicy{
+		FailurePolicyError,
+		FailurePolicyWarning,
+		FailurePolicyNone,
+		FailurePolicyNever,
+	} {
+		parsedPolicy, err := ParseFailurePolicy(policy.String())
+
+		assert.Nil(t, err)
+		assert.Equal(t, policy, parsedPolicy)
+	}
+}
+
+func TestParseInvalidFailurePolicy(t *testing.T) {
+	_, err := ParseFailurePolicy("always")
+
+	assert.NotNil(t, err)
+}
+
+func TestFailurePolicyEvaluate(t *testing.T) {
+	testCases := []struct {
+		policy    FailurePolicy
+		succeeded []AnalysisStatus
+		failed    []AnalysisStatus
+	}{
+		{
+			policy:    FailurePolicyError,
+			succeeded: []AnalysisStatus{AnalysisStatusOk, AnalysisStatusWarning, AnalysisStatusNone},
+			failed:    []AnalysisStatus{AnalysisStatusError},
+		},
+		{
+			policy:    FailurePolicyWarning,
+			succeeded: []AnalysisStatus{AnalysisStatusOk, AnalysisStatusNone},
+			failed:    []AnalysisStatus{AnalysisStatusWarning, AnalysisStatusError},
+		},
+		{
+			policy:    FailurePolicyNone,
+			succeeded: []AnalysisStatus{AnalysisStatusOk},
+			failed:    []AnalysisStatus{AnalysisStatusWarning, AnalysisStatusError, AnalysisStatusNone},
+		},
+		{
+			policy:    FailurePolicyNever,
+			succeeded: []AnalysisStatus{AnalysisStatusOk, AnalysisStatusWarning, AnalysisStatusError, AnalysisStatusNone},
+		},
+	}
+
+	for _, testCase := range testCases {
+		for _, analysisStatus := range testCase.succeeded {
+			exitCode, err := testCase.policy.Evaluate(ProjectAnalysisStatus{
+				TaskStatus:     TaskStatusSuccess,
+				AnalysisStatus: analysisStatus,
+			})
+
+			assert.Nil(t, err, "%s on %s", testCase.policy, analysisStatus)
+			assert.Equal(t, ExitCodeSuccess, exitCode, "%s on %s", testCase.policy, analysisStatus)
+		}
+
+		for _, analysisStatus := range testCase.failed {
+			exitCode, err := testCase.policy.Evaluate(ProjectAnalysisStatus{
+				TaskStatus:     TaskStatusSuccess,
+				AnalysisStatus: analysisStatus,
+			})
+
+			assert.EqualError(
+				t,
+				err,
+				fmt.Sprintf("quality gate failed with the status '%s'", analysisStatus),
+				"%s on %s",
+				testCase.policy,
+				analysisStatus,
+			)
+			assert.Equal(t, ExitCodeQualityGateFailed, exitCode, "%s on %s", testCase.policy, analysisStatus)
+		}
+	}
+}
+
+func TestFailurePolicyEvaluateFailedTask(t *testing.T) {
+	for _, taskStatus := range []TaskStatus{TaskStatusFailed, TaskStatusCancelled} {
+		exitCode, err := FailurePolicyNever.Evaluate(ProjectAnalysisStatus{TaskStatus: taskStatus})
+
+		assert.EqualError(t, err, fmt.Sprintf("analysis task failed with the status '%s'", taskStatus))
+		assert.Equal(t, ExitCodeTaskFailed, exitCode)
+	}
+}
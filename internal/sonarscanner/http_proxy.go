@@ -0,0 +1,88 @@
+package sonarscanner
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// httpProxyEnvVars lists the environment variables http.ProxyFromEnvironment
+// takes the proxy url from.
+var httpProxyEnvVars = []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy"}
+
+// httpProxyFunc picks the outbound proxy for a request to the sonar host, see
+// http.Transport.Proxy.
+type httpProxyFunc func(*http.Request) (*url.URL, error)
+
+// getHttpProxyFunc returns the proxy function using the explicitly given proxy
+// url, if any, or the one from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables otherwise. Credentials in the proxy url are sent in the
+// Proxy-Authorization header, https requests are tunnelled with CONNECT.
+func getHttpProxyFunc(httpProxy string) (httpProxyFunc, error) {
+	if httpProxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyUrl, err := url.Parse(httpProxy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the http proxy url: %s", err)
+	}
+
+	switch proxyUrl.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported http proxy url scheme '%s'", proxyUrl.Scheme)
+	}
+
+	if proxyUrl.Host == "" {
+		return nil, fmt.Errorf("http proxy url '%s' has no host", proxyUrl.Redacted())
+	}
+
+	return http.ProxyURL(proxyUrl), nil
+}
+
+func newHttpTransport(config *tls.Config, proxy httpProxyFunc) *http.Transport {
+	return &http.Transport{
+		TLSClientConfig: config,
+		Proxy:           proxy,
+	}
+}
+
+// getHttpProxyPasswords returns the passwords of the proxy urls the function
+// returned by getHttpProxyFunc may use, so they can be masked in the output.
+func getHttpProxyPasswords(httpProxy string) []string {
+	values := []string{httpProxy}
+	if httpProxy == "" {
+		values = values[:0]
+		for _, name := range httpProxyEnvVars {
+			values = append(values, os.Getenv(name))
+		}
+	}
+
+	var passwords []string
+	for _, value := range values {
+		if password := getUrlPassword(value); password != "" {
+			passwords = append(passwords, password)
+		}
+	}
+
+	return passwords
+}
+
+// getUrlPassword returns the password of the url. Like the proxy environment
+// variables, the url may omit the scheme, which is http then.
+func getUrlPassword(value string) string {
+	parsedUrl, err := url.Parse(value)
+	if err != nil || parsedUrl.User == nil {
+		parsedUrl, err = url.Parse("http://" + value)
+	}
+
+	if err != nil || parsedUrl.User == nil {
+		return ""
+	}
+
+	password, _ := parsedUrl.User.Password()
+	return password
+}
@@ -0,0 +1,114 @@
+package sonarscanner
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const (
+	PollStrategyFixed       = "fixed"
+	PollStrategyExponential = "exponential"
+
+	exponentialPollMultiplier = 2
+)
+
+// PollStrategy determines how long to wait before polling the analysis task
+// status again.
+type PollStrategy interface {
+	// NextDelay returns the delay before the next poll given the number of
+	// polls made so far, which is at least 1.
+	NextDelay(polls int) time.Duration
+}
+
+// FixedPollStrategy waits for the same interval between polls.
+type FixedPollStrategy struct {
+	Interval time.Duration
+}
+
+// ExponentialPollStrategy doubles the interval after every poll, starting with
+// the Interval and never exceeding the MaxInterval.
+type ExponentialPollStrategy struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+}
+
+// JitterPollStrategy randomly spreads the delays of the wrapped strategy by up
+// to the Factor of the delay in both directions.
+type JitterPollStrategy struct {
+	Strategy PollStrategy
+	Factor   float64
+	random   func() float64
+}
+
+var defaultPollStrategy = &FixedPollStrategy{Interval: defaultWaitTimeout}
+
+// NewPollStrategy creates either a fixed or exponential poll strategy with the
+// optional jitter, the jitter being a fraction of the delay between 0 and 1.
+func NewPollStrategy(kind string, interval, maxInterval time.Duration, jitter float64) (PollStrategy, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive")
+	}
+
+	if jitter < 0 || jitter > 1 {
+		return nil, fmt.Errorf("poll jitter must be between 0 and 1")
+	}
+
+	var strategy PollStrategy
+	switch kind {
+	case PollStrategyFixed:
+		strategy = &FixedPollStrategy{Interval: interval}
+	case PollStrategyExponential:
+		if maxInterval < interval {
+			return nil, fmt.Errorf("max poll interval must not be less than the poll interval")
+		}
+
+		strategy = &ExponentialPollStrategy{Interval: interval, MaxInterval: maxInterval}
+	default:
+		return nil, fmt.Errorf("unknown poll strategy '%s'", kind)
+	}
+
+	if jitter != 0 {
+		strategy = &JitterPollStrategy{Strategy: strategy, Factor: jitter}
+	}
+
+	return strategy, nil
+}
+
+func (s *FixedPollStrategy) NextDelay(polls int) time.Duration {
+	return s.Interval
+}
+
+func (s *ExponentialPollStrategy) NextDelay(polls int) time.Duration {
+	delay := s.Interval
+	for i := 1; i < polls && delay < s.MaxInterval; i++ {
+		delay *= exponentialPollMultiplier
+	}
+
+	if delay > s.MaxInterval {
+		return s.MaxInterval
+	}
+
+	return delay
+}
+
+func (s *JitterPollStrategy) NextDelay(polls int) time.Duration {
+	random := s.random
+	if random == nil {
+		random = rand.Float64
+	}
+
+	delay := s.Strategy.NextDelay(polls)
+	return delay + time.Duration(float64(delay)*s.Factor*(2*random()-1))
+}
+
+// nextPollDelay returns the delay before the next poll, which is never less
+// than the one the server asked for in the Retry-After header.
+func nextPollDelay(strategy PollStrategy, polls int, retryAfter time.Duration) time.Duration {
+	delay := strategy.NextDelay(polls)
+	if retryAfter > delay {
+		return retryAfter
+	}
+
+	return delay
+}
@@ -0,0 +1,87 @@
+package sonarscanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedPollStrategy(t *testing.T) {
+	strategy := &FixedPollStrategy{Interval: time.Second}
+
+	assert.Equal(t, time.Second, strategy.NextDelay(1))
+	assert.Equal(t, time.Second, strategy.NextDelay(10))
+}
+
+func TestExponentialPollStrategy(t *testing.T) {
+	strategy := &ExponentialPollStrategy{Interval: time.Second, MaxInterval: 10 * time.Second}
+
+	assert.Equal(t, time.Second, strategy.NextDelay(1))
+	assert.Equal(t, 2*time.Second, strategy.NextDelay(2))
+	assert.Equal(t, 4*time.Second, strategy.NextDelay(3))
+	assert.Equal(t, 8*time.Second, strategy.NextDelay(4))
+	assert.Equal(t, 10*time.Second, strategy.NextDelay(5))
+	assert.Equal(t, 10*time.Second, strategy.NextDelay(1000))
+}
+
+func TestJitterPollStrategy(t *testing.T) {
+	strategy := &JitterPollStrategy{
+		Strategy: &FixedPollStrategy{Interval: 10 * time.Second},
+		Factor:   0.5,
+	}
+
+	strategy.random = func() float64 { return 0 }
+	assert.Equal(t, 5*time.Second, strategy.NextDelay(1))
+
+	strategy.random = func() float64 { return 0.5 }
+	assert.Equal(t, 10*time.Second, strategy.NextDelay(1))
+
+	strategy.random = func() float64 { return 0.75 }
+	assert.Equal(t, 12500*time.Millisecond, strategy.NextDelay(1))
+
+	strategy.random = nil
+	for i := 0; i < 100; i++ {
+		delay := strategy.NextDelay(1)
+
+		assert.True(t, delay >= 5*time.Second && delay <= 15*time.Second)
+	}
+}
+
+func TestNewPollStrategy(t *testing.T) {
+	strategy, err := NewPollStrategy(PollStrategyFixed, time.Second, 0, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &FixedPollStrategy{Interval: time.Second}, strategy)
+
+	strategy, err = NewPollStrategy(PollStrategyExponential, time.Second, time.Minute, 0.1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &JitterPollStrategy{
+		Strategy: &ExponentialPollStrategy{Interval: time.Second, MaxInterval: time.Minute},
+		Factor:   0.1,
+	}, strategy)
+}
+
+func TestNewPollStrategyInvalidParameters(t *testing.T) {
+	assertInvalidPollStrategy(t, "linear", time.Second, time.Minute, 0)
+	assertInvalidPollStrategy(t, PollStrategyFixed, 0, time.Minute, 0)
+	assertInvalidPollStrategy(t, PollStrategyFixed, time.Second, time.Minute, 1.5)
+	assertInvalidPollStrategy(t, PollStrategyFixed, time.Second, time.Minute, -0.1)
+	assertInvalidPollStrategy(t, PollStrategyExponential, time.Minute, time.Second, 0)
+}
+
+func TestNextPollDelay(t *testing.T) {
+	strategy := &FixedPollStrategy{Interval: 2 * time.Second}
+
+	assert.Equal(t, 2*time.Second, nextPollDelay(strategy, 1, 0))
+	assert.Equal(t, 2*time.Second, nextPollDelay(strategy, 1, time.Second))
+	assert.Equal(t, 5*time.Second, nextPollDelay(strategy, 1, 5*time.Second))
+}
+
+func assertInvalidPollStrategy(t *testing.T, kind string, interval, maxInterval time.Duration, jitter float64) {
+	strategy, err := NewPollStrategy(kind, interval, maxInterval, jitter)
+
+	assert.NotNil(t, err)
+	assert.Nil(t, strategy)
+}
@@ -21,21 +21,19 @@ func readProjectProperties(projectFile string) (*projectProperties, error) {
 
 	defer file.Close()
 
-	props := &projectProperties{}
-	reader := properties.NewReader(file)
-	for reader.Scan() {
-		switch reader.Key() {
-		case "sonar.host.url":
-			props.sonarHostUrl = reader.Value()
-			break
-		case "sonar.login":
-			props.login = reader.Value()
-			break
-		case "sonar.password":
-			props.password = reader.Value()
-			break
-		}
+	props, err := properties.Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read properties file: %s", err)
+	}
+
+	props, err = props.Interpolate(os.LookupEnv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpolate properties: %s", err)
 	}
 
-	return props, reader.Err()
+	return &projectProperties{
+		sonarHostUrl: props["sonar.host.url"],
+		login:        props["sonar.login"],
+		password:     props["sonar.password"],
+	}, nil
 }
@@ -38,3 +38,44 @@ func TestReadProjectPropertiesInvalidFile(t *testing.T) {
 	assert.Nil(t, props)
 	assert.NotNil(t, err)
 }
+
+func TestReadProjectPropertiesInterpolated(t *testing.T) {
+	tempDir := t.TempDir()
+	propertiesFileName := path.Join(tempDir, "properties.properties")
+
+	os.Setenv("TEST_SONAR_URL", "http://sonarqube.local")
+	defer os.Unsetenv("TEST_SONAR_URL")
+
+	file, _ := os.Create(propertiesFileName)
+	file.WriteString(`
+    sonar.host.url = ${env.TEST_SONAR_URL}
+    sonar.login = ${project.login}
+    sonar.password = ${env.TEST_UNDEFINED_PASSWORD}
+    project.login = login
+    `)
+	file.Close()
+
+	props, err := readProjectProperties(propertiesFileName)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, props)
+	assert.Equal(t, props.sonarHostUrl, "http://sonarqube.local")
+	assert.Equal(t, props.login, "login")
+	assert.Equal(t, props.password, "")
+}
+
+func TestReadProjectPropertiesCyclicReference(t *testing.T) {
+	tempDir := t.TempDir()
+	propertiesFileName := path.Join(tempDir, "properties.properties")
+
+	file, _ := os.Create(propertiesFileName)
+	file.WriteString(`
+    sonar.host.url = ${sonar.host.url}
+    `)
+	file.Close()
+
+	props, err := readProjectProperties(propertiesFileName)
+
+	assert.Nil(t, props)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,63 @@
+package sonarscanner
+
+import (
+	"fmt"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarapi"
+)
+
+type QualityGateCondition struct {
+	MetricKey      string
+	Comparator     string
+	ErrorThreshold string
+	ActualValue    string
+	Status         AnalysisStatus
+}
+
+type QualityGateReport struct {
+	Status     AnalysisStatus
+	Conditions []QualityGateCondition
+}
+
+var undefinedQualityGateReport = QualityGateReport{Status: AnalysisStatusUndefined}
+
+// FailedConditions returns the conditions the project didn't meet.
+func (report QualityGateReport) FailedConditions() []QualityGateCondition {
+	var conditions []QualityGateCondition
+	for _, condition := range report.Conditions {
+		if condition.Status == AnalysisStatusError || condition.Status == AnalysisStatusWarning {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	return conditions
+}
+
+func parseQualityGateReport(projectStatus *sonarapi.ProjectStatus) (QualityGateReport, error) {
+	status, err := parseAnalysisStatus(projectStatus.Status)
+	if err != nil {
+		return undefinedQualityGateReport, err
+	}
+
+	report := QualityGateReport{Status: status}
+	for _, condition := range projectStatus.Conditions {
+		conditionStatus, err := parseAnalysisStatus(condition.Status)
+		if err != nil {
+			return undefinedQualityGateReport, fmt.Errorf(
+				"condition on metric '%s': %s",
+				condition.MetricKey,
+				err,
+			)
+		}
+
+		report.Conditions = append(report.Conditions, QualityGateCondition{
+			MetricKey:      condition.MetricKey,
+			Comparator:     condition.Comparator,
+			ErrorThreshold: condition.ErrorThreshold,
+			ActualValue:    condition.ActualValue,
+			Status:         conditionStatus,
+		})
+	}
+
+	return report, nil
+}
@@ -0,0 +1,73 @@
+package sonarscanner
+
+import (
+	"testing"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQualityGateReport(t *testing.T) {
+	projectStatus := &sonarapi.ProjectStatus{
+		Status: "ERROR",
+		Conditions: []sonarapi.QualityGateCondition{
+			{
+				Status:         "ERROR",
+				MetricKey:      "new_coverage",
+				Comparator:     "LT",
+				ErrorThreshold: "85",
+				ActualValue:    "82.5",
+			},
+			{
+				Status:         "OK",
+				MetricKey:      "new_bugs",
+				Comparator:     "GT",
+				ErrorThreshold: "0",
+				ActualValue:    "0",
+			},
+		},
+	}
+
+	report, err := parseQualityGateReport(projectStatus)
+
+	assert.Nil(t, err)
+	assert.Equal(t, AnalysisStatusError, report.Status)
+	assert.Equal(t, []QualityGateCondition{
+		{
+			MetricKey:      "new_coverage",
+			Comparator:     "LT",
+			ErrorThreshold: "85",
+			ActualValue:    "82.5",
+			Status:         AnalysisStatusError,
+		},
+		{
+			MetricKey:      "new_bugs",
+			Comparator:     "GT",
+			ErrorThreshold: "0",
+			ActualValue:    "0",
+			Status:         AnalysisStatusOk,
+		},
+	}, report.Conditions)
+	assert.Equal(t, report.Conditions[:1], report.FailedConditions())
+}
+
+func TestParseQualityGateReportWithoutConditions(t *testing.T) {
+	report, err := parseQualityGateReport(&sonarapi.ProjectStatus{Status: "NONE"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, AnalysisStatusNone, report.Status)
+	assert.Empty(t, report.Conditions)
+	assert.Empty(t, report.FailedConditions())
+}
+
+func TestParseQualityGateReportInvalidConditionStatus(t *testing.T) {
+	projectStatus := &sonarapi.ProjectStatus{
+		Status:     "OK",
+		Conditions: []sonarapi.QualityGateCondition{{Status: "MAYBE", MetricKey: "new_bugs"}},
+	}
+
+	report, err := parseQualityGateReport(projectStatus)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, AnalysisStatusUndefined, report.Status)
+}
@@ -0,0 +1,81 @@
+package sonarscanner
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/properties"
+)
+
+// ReportTask is the metadata sonar-scanner writes to the report-task.txt file
+// after the analysis report has been submitted.
+type ReportTask struct {
+	ProjectKey    string
+	ServerUrl     string
+	ServerVersion string
+	DashboardUrl  string
+	CeTaskId      string
+	CeTaskUrl     string
+}
+
+func readReportTaskFromFile(fileName string) (*ReportTask, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return readReportTask(file)
+}
+
+func readReportTask(reader io.Reader) (*ReportTask, error) {
+	props, err := properties.Read(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	task := &ReportTask{
+		ProjectKey:    props["projectKey"],
+		ServerUrl:     props["serverUrl"],
+		ServerVersion: props["serverVersion"],
+		DashboardUrl:  props["dashboardUrl"],
+		CeTaskId:      props["ceTaskId"],
+		CeTaskUrl:     props["ceTaskUrl"],
+	}
+	if task.CeTaskId == "" {
+		return nil, errors.New("metadata file doesn't contain task id")
+	}
+
+	if task.CeTaskUrl == "" {
+		return nil, errors.New("metadata file doesn't contain task url")
+	}
+
+	return task, nil
+}
+
+// rebase returns a copy of the task with the urls pointing to the sonarHostUrl
+// instead of the server url sonar-scanner was given, i.e. the reverse proxy.
+func (t *ReportTask) rebase(sonarHostUrl string) *ReportTask {
+	task := *t
+	if t.ServerUrl == "" {
+		return &task
+	}
+
+	task.ServerUrl = sonarHostUrl
+	task.DashboardUrl = rebaseUrl(t.DashboardUrl, t.ServerUrl, sonarHostUrl)
+	task.CeTaskUrl = rebaseUrl(t.CeTaskUrl, t.ServerUrl, sonarHostUrl)
+
+	return &task
+}
+
+func rebaseUrl(value, from, to string) string {
+	from = strings.TrimSuffix(from, "/")
+	if value != from && !strings.HasPrefix(value, from+"/") {
+		return value
+	}
+
+	return strings.TrimSuffix(to, "/") + strings.TrimPrefix(value, from)
+}
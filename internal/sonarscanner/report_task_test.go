@@ -0,0 +1,108 @@
+package sonarscanner
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadReportTask(t *testing.T) {
+	reader := strings.NewReader(`
+	projectKey=project
+	serverUrl=http://localhost:6969
+	serverVersion=8.6.0.39681
+	dashboardUrl=http://localhost:6969/dashboard?id=project
+	ceTaskId=AXoTaskId
+	ceTaskUrl=http://localhost:6969/api/ce/task?id=AXoTaskId
+	`)
+
+	task, err := readReportTask(reader)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &ReportTask{
+		ProjectKey:    "project",
+		ServerUrl:     "http://localhost:6969",
+		ServerVersion: "8.6.0.39681",
+		DashboardUrl:  "http://localhost:6969/dashboard?id=project",
+		CeTaskId:      "AXoTaskId",
+		CeTaskUrl:     "http://localhost:6969/api/ce/task?id=AXoTaskId",
+	}, task)
+}
+
+func TestReadReportTaskWithoutTaskUrl(t *testing.T) {
+	reader := strings.NewReader(`
+	projectKey=project
+	ceTaskId=AXoTaskId
+	`)
+
+	task, err := readReportTask(reader)
+
+	assert.NotNil(t, err)
+	assert.Nil(t, task)
+}
+
+func TestReadReportTaskWithoutTaskId(t *testing.T) {
+	reader := strings.NewReader(`
+	projectKey=project
+	ceTaskUrl=http://localhost:6969/api/ce/task?id=AXoTaskId
+	`)
+
+	task, err := readReportTask(reader)
+
+	assert.NotNil(t, err)
+	assert.Nil(t, task)
+}
+
+func TestReadReportTaskFromFile(t *testing.T) {
+	tempDir := t.TempDir()
+	metadataFileName := path.Join(tempDir, "report-task.txt")
+
+	file, _ := os.Create(metadataFileName)
+	file.WriteString("ceTaskId=task-id\nceTaskUrl=http://poll-me/?q=1")
+	file.Close()
+
+	task, err := readReportTaskFromFile(metadataFileName)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "http://poll-me/?q=1", task.CeTaskUrl)
+}
+
+func TestReadReportTaskFromFileInvalidFile(t *testing.T) {
+	tempDir := t.TempDir()
+	metadataFileName := path.Join(tempDir, "nonexistent-report-task.txt")
+
+	task, err := readReportTaskFromFile(metadataFileName)
+
+	assert.NotNil(t, err)
+	assert.Nil(t, task)
+}
+
+func TestReportTaskRebase(t *testing.T) {
+	task := &ReportTask{
+		ProjectKey:   "project",
+		ServerUrl:    "http://localhost:6969/",
+		DashboardUrl: "http://localhost:6969/dashboard?id=project",
+		CeTaskId:     "AXoTaskId",
+		CeTaskUrl:    "http://localhost:6969/api/ce/task?id=AXoTaskId",
+	}
+
+	rebased := task.rebase("https://sonarqube.local/sonar/")
+
+	assert.Equal(t, &ReportTask{
+		ProjectKey:   "project",
+		ServerUrl:    "https://sonarqube.local/sonar/",
+		DashboardUrl: "https://sonarqube.local/sonar/dashboard?id=project",
+		CeTaskId:     "AXoTaskId",
+		CeTaskUrl:    "https://sonarqube.local/sonar/api/ce/task?id=AXoTaskId",
+	}, rebased)
+	assert.Equal(t, "http://localhost:6969/api/ce/task?id=AXoTaskId", task.CeTaskUrl)
+}
+
+func TestRebaseUrl(t *testing.T) {
+	assert.Equal(t, "http://host/path", rebaseUrl("http://proxy/path", "http://proxy", "http://host/"))
+	assert.Equal(t, "http://host", rebaseUrl("http://proxy", "http://proxy/", "http://host"))
+	assert.Equal(t, "http://proxy2/path", rebaseUrl("http://proxy2/path", "http://proxy", "http://host"))
+}
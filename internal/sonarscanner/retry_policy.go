@@ -0,0 +1,44 @@
+package sonarscanner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarapi"
+)
+
+const (
+	defaultRetryMaxAttempts = 4
+	defaultRetryInterval    = time.Second
+	defaultRetryMaxInterval = 10 * time.Second
+)
+
+var defaultRetryPolicy = &sonarapi.RetryPolicy{
+	MaxAttempts: defaultRetryMaxAttempts,
+	Backoff: &ExponentialPollStrategy{
+		Interval:    defaultRetryInterval,
+		MaxInterval: defaultRetryMaxInterval,
+	},
+}
+
+// NewRetryPolicy creates a retry policy making up to the maxAttempts attempts
+// with an exponential backoff starting with the interval.
+func NewRetryPolicy(maxAttempts int, interval time.Duration) (*sonarapi.RetryPolicy, error) {
+	if maxAttempts < 1 {
+		return nil, fmt.Errorf("max request attempts must be positive")
+	}
+
+	if interval <= 0 {
+		return nil, fmt.Errorf("request retry interval must be positive")
+	}
+
+	maxInterval := defaultRetryMaxInterval
+	if interval > maxInterval {
+		maxInterval = interval
+	}
+
+	return &sonarapi.RetryPolicy{
+		MaxAttempts: maxAttempts,
+		Backoff:     &ExponentialPollStrategy{Interval: interval, MaxInterval: maxInterval},
+	}, nil
+}
@@ -0,0 +1,25 @@
+package sonarscanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy, err := NewRetryPolicy(3, 2*time.Second)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, policy.MaxAttempts)
+	assert.Equal(t, 2*time.Second, policy.Backoff.NextDelay(1))
+	assert.Equal(t, defaultRetryMaxInterval, policy.Backoff.NextDelay(10))
+}
+
+func TestNewRetryPolicyInvalid(t *testing.T) {
+	_, err := NewRetryPolicy(0, time.Second)
+	assert.NotNil(t, err)
+
+	_, err = NewRetryPolicy(1, 0)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,53 @@
+package sonarscanner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// defaultScannerName is the name of the sonar-scanner executable looked up in
+// the PATH, the lookup takes the executable extensions into account on Windows.
+const defaultScannerName = "sonar-scanner"
+
+// findScanner returns the path of the sonar-scanner executable. The scanner path
+// is either the executable itself or the sonar-scanner installation directory,
+// the executable is looked up in the PATH if it's empty.
+func findScanner(scannerPath string) (string, error) {
+	if scannerPath == "" {
+		path, err := exec.LookPath(defaultScannerName)
+		if err != nil {
+			return "", fmt.Errorf("sonar-scanner wasn't found in the PATH: %s", err)
+		}
+
+		return path, nil
+	}
+
+	stat, err := os.Stat(scannerPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid sonar-scanner path: %s", err)
+	}
+
+	if !stat.IsDir() {
+		return scannerPath, nil
+	}
+
+	path := filepath.Join(scannerPath, "bin", scannerExecutableName())
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("sonar-scanner installation directory %s doesn't contain the executable: %s", scannerPath, err)
+	}
+
+	return path, nil
+}
+
+// scannerExecutableName returns the name of the executable in the bin directory
+// of a sonar-scanner installation.
+func scannerExecutableName() string {
+	if runtime.GOOS == "windows" {
+		return defaultScannerName + ".bat"
+	}
+
+	return defaultScannerName
+}
@@ -0,0 +1,65 @@
+package sonarscanner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindScannerExecutable(t *testing.T) {
+	scannerPath := filepath.Join(t.TempDir(), "scanner")
+	assert.Nil(t, ioutil.WriteFile(scannerPath, []byte("#!/bin/sh\n"), 0755))
+
+	path, err := findScanner(scannerPath)
+
+	assert.Nil(t, err)
+	assert.Equal(t, scannerPath, path)
+}
+
+func TestFindScannerInstallationDirectory(t *testing.T) {
+	scannerHome := t.TempDir()
+	assert.Nil(t, os.Mkdir(filepath.Join(scannerHome, "bin"), 0755))
+	scannerPath := filepath.Join(scannerHome, "bin", scannerExecutableName())
+	assert.Nil(t, ioutil.WriteFile(scannerPath, []byte("#!/bin/sh\n"), 0755))
+
+	path, err := findScanner(scannerHome)
+
+	assert.Nil(t, err)
+	assert.Equal(t, scannerPath, path)
+}
+
+func TestFindScannerEmptyInstallationDirectory(t *testing.T) {
+	_, err := findScanner(t.TempDir())
+
+	assert.NotNil(t, err)
+}
+
+func TestFindScannerNonExistentPath(t *testing.T) {
+	_, err := findScanner(filepath.Join(t.TempDir(), "sonar-scanner"))
+
+	assert.NotNil(t, err)
+}
+
+func TestFindScannerInPath(t *testing.T) {
+	dir := t.TempDir()
+	scannerPath := filepath.Join(dir, defaultScannerName)
+	assert.Nil(t, ioutil.WriteFile(scannerPath, []byte("#!/bin/sh\n"), 0755))
+
+	originalPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir)
+	defer os.Setenv("PATH", originalPath)
+
+	path, err := findScanner("")
+
+	assert.Nil(t, err)
+	assert.Equal(t, scannerPath, path)
+
+	os.Setenv("PATH", t.TempDir())
+
+	_, err = findScanner("")
+
+	assert.NotNil(t, err)
+}
@@ -2,11 +2,17 @@ package sonarscanner
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
 	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
@@ -14,12 +20,18 @@ import (
 type sonarHostProxyFactory struct {
 	listenAddr   string
 	sonarHostUrl string
+	token        string
+	login        string
+	password     string
 	config       *tls.Config
+	httpProxy    httpProxyFunc
 	log          *logrus.Entry
 }
 
 type sonarHostProxy struct {
 	listenAddr string
+	token      string
+	listener   net.Listener
 	log        *logrus.Entry
 	proxy      *httputil.ReverseProxy
 }
@@ -31,33 +43,67 @@ func (f *sonarHostProxyFactory) new() (*sonarHostProxy, error) {
 	}
 
 	proxy := httputil.NewSingleHostReverseProxy(target)
-	proxy.Transport = &http.Transport{
-		TLSClientConfig: f.config,
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+
+		// The sonar host may be routed by the host name, e.g. by an ingress.
+		req.Host = target.Host
+
+		// Credentials are never passed to sonar-scanner, it authenticates with
+		// the proxy token instead, which is replaced with the configured
+		// credentials. Tokens are sent with the bearer scheme, a login and
+		// password with the basic one.
+		req.Header.Del("Authorization")
+		if f.login != "" && f.password == "" {
+			req.Header.Set("Authorization", "Bearer "+f.login)
+		} else if f.login != "" {
+			req.SetBasicAuth(f.login, f.password)
+		}
 	}
+	proxy.Transport = newHttpTransport(f.config, f.httpProxy)
 
 	return &sonarHostProxy{
 		listenAddr: f.listenAddr,
+		token:      f.token,
 		log:        f.log,
 		proxy:      proxy,
 	}, nil
 }
 
-func (p *sonarHostProxy) serveWithContext(ctx context.Context) error {
-	p.log.Infof("Starting reverse proxy on %s ...", p.listenAddr)
-
-	server := &http.Server{
-		Addr: p.listenAddr,
-		Handler: http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
-			p.log.Debugf("Proxying request %s %s", req.Method, req.URL)
-			p.proxy.ServeHTTP(res, req)
-		}),
+// listen binds the proxy to the listen address, which may have a zero port, and
+// returns the actual address the proxy listens on. Once listen returns, the
+// connections to the address are accepted, even though they won't be served
+// until serveWithContext is called.
+func (p *sonarHostProxy) listen() (string, error) {
+	listener, err := net.Listen("tcp", p.listenAddr)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen on %s: %s", p.listenAddr, err)
 	}
 
-	if err := server.ListenAndServe(); err != nil {
-		return err
+	p.listener = listener
+	p.log.Infof("Reverse proxy is listening on %s", listener.Addr())
+
+	return listener.Addr().String(), nil
+}
+
+// serveWithContext serves the connections accepted by the listener until the
+// context is done. The listen method must be called first.
+func (p *sonarHostProxy) serveWithContext(ctx context.Context) error {
+	if p.listener == nil {
+		return errors.New("reverse proxy is not listening")
 	}
 
+	server := &http.Server{Handler: p}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(p.listener)
+	}()
+
 	select {
+	case err := <-errs:
+		return err
 	case <-ctx.Done():
 		p.log.Info("Stopping the reverse proxy ...")
 		if err := server.Close(); err != nil {
@@ -66,6 +112,46 @@ func (p *sonarHostProxy) serveWithContext(ctx context.Context) error {
 			p.log.Info("Reverse proxy stopped")
 		}
 
+		if err := <-errs; err != http.ErrServerClosed {
+			return err
+		}
+
 		return nil
 	}
 }
+
+// ServeHTTP proxies the requests authenticated with the proxy token and rejects
+// the rest, so that the other local processes can't use the credentials.
+func (p *sonarHostProxy) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if !isProxyTokenValid(req, p.token) {
+		p.log.Warnf("Rejecting request %s %s without a valid proxy token", req.Method, req.URL)
+		http.Error(res, "invalid proxy token", http.StatusUnauthorized)
+		return
+	}
+
+	p.log.Debugf("Proxying request %s %s", req.Method, req.URL)
+	p.proxy.ServeHTTP(res, req)
+}
+
+// isProxyTokenValid tells whether the request carries the proxy token, either
+// as the basic auth login, which sonar-scanner sends for sonar.login, or as the
+// bearer token, which it sends for sonar.token.
+func isProxyTokenValid(req *http.Request, token string) bool {
+	credential, _, ok := req.BasicAuth()
+	if authorization := req.Header.Get("Authorization"); !ok && strings.HasPrefix(authorization, "Bearer ") {
+		credential = strings.TrimPrefix(authorization, "Bearer ")
+	}
+
+	return token != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(token)) == 1
+}
+
+// newProxyToken generates the random token sonar-scanner authenticates to the
+// sonar host proxy with.
+func newProxyToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
@@ -1,8 +1,15 @@
 package sonarscanner
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,3 +36,177 @@ func TestSonarHostProxyNewInvalidUrl(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, proxy)
 }
+
+func TestSonarHostProxyServeOnDynamicPort(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(req.URL.Path))
+	}))
+	defer server.Close()
+
+	factory := &sonarHostProxyFactory{
+		listenAddr:   "localhost:0",
+		sonarHostUrl: server.URL,
+		token:        "proxy-token",
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	proxy, err := factory.new()
+	assert.Nil(t, err)
+
+	addr, err := proxy.listen()
+	assert.Nil(t, err)
+	assert.NotEqual(t, "localhost:0", addr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- proxy.serveWithContext(ctx)
+	}()
+
+	request, _ := http.NewRequest("GET", fmt.Sprintf("http://%s/api/server/version", addr), nil)
+	request.SetBasicAuth("proxy-token", "")
+
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+
+	body, _ := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+
+	assert.Equal(t, "/api/server/version", string(body))
+
+	cancel()
+
+	assert.Nil(t, <-errs)
+
+	_, err = http.Get(fmt.Sprintf("http://%s/api/server/version", addr))
+	assert.NotNil(t, err)
+}
+
+func TestSonarHostProxyListenFailure(t *testing.T) {
+	factory := &sonarHostProxyFactory{
+		listenAddr:   "localhost:0",
+		sonarHostUrl: "http://sonarqube.local",
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	proxy, err := factory.new()
+	assert.Nil(t, err)
+
+	addr, err := proxy.listen()
+	assert.Nil(t, err)
+
+	defer proxy.listener.Close()
+
+	factory.listenAddr = addr
+	otherProxy, err := factory.new()
+	assert.Nil(t, err)
+
+	otherAddr, err := otherProxy.listen()
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "", otherAddr)
+}
+
+func TestSonarHostProxyServeWithoutListen(t *testing.T) {
+	factory := &sonarHostProxyFactory{
+		listenAddr:   "localhost:0",
+		sonarHostUrl: "http://sonarqube.local",
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	proxy, err := factory.new()
+	assert.Nil(t, err)
+
+	assert.NotNil(t, proxy.serveWithContext(context.Background()))
+}
+
+func TestSonarHostProxyInjectsCredentials(t *testing.T) {
+	assertProxiedAuthorization(t, "token", "", "Bearer token")
+	assertProxiedAuthorization(t, "login", "password", "Basic bG9naW46cGFzc3dvcmQ=")
+	assertProxiedAuthorization(t, "", "", "")
+}
+
+func assertProxiedAuthorization(t *testing.T, login, password, expectedAuthorization string) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(req.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	factory := &sonarHostProxyFactory{
+		listenAddr:   "localhost:0",
+		sonarHostUrl: server.URL,
+		login:        login,
+		password:     password,
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	proxy, err := factory.new()
+	assert.Nil(t, err)
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest("GET", "http://localhost/api/server/version", nil)
+	request.SetBasicAuth("scanner-login", "scanner-password")
+
+	proxy.proxy.ServeHTTP(recorder, request)
+
+	assert.Equal(t, expectedAuthorization, recorder.Body.String())
+}
+
+func TestSonarHostProxyRejectsRequestsWithoutToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(req.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	factory := &sonarHostProxyFactory{
+		listenAddr:   "localhost:0",
+		sonarHostUrl: server.URL,
+		token:        "proxy-token",
+		login:        "token",
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	proxy, err := factory.new()
+	assert.Nil(t, err)
+
+	for authorization, expectedCode := range map[string]int{
+		"":                           http.StatusUnauthorized,
+		"Basic c2Nhbm5lci1sb2dpbjo=": http.StatusUnauthorized,
+		"Bearer other-token":         http.StatusUnauthorized,
+		"proxy-token":                http.StatusUnauthorized,
+		"Basic cHJveHktdG9rZW46":     http.StatusOK,
+		"Bearer proxy-token":         http.StatusOK,
+	} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest("GET", "http://localhost/api/server/version", nil)
+		request.Header.Set("Authorization", authorization)
+
+		proxy.ServeHTTP(recorder, request)
+
+		assert.Equal(t, expectedCode, recorder.Code, authorization)
+		if expectedCode == http.StatusOK {
+			assert.Equal(t, "Bearer token", recorder.Body.String(), authorization)
+		}
+	}
+}
+
+func TestSonarHostProxySetsTargetHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte(req.Host))
+	}))
+	defer server.Close()
+
+	factory := &sonarHostProxyFactory{
+		listenAddr:   "localhost:0",
+		sonarHostUrl: server.URL,
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	proxy, err := factory.new()
+	assert.Nil(t, err)
+
+	recorder := httptest.NewRecorder()
+	proxy.proxy.ServeHTTP(recorder, httptest.NewRequest("GET", "http://localhost:6969/api/server/version", nil))
+
+	assert.Equal(t, strings.TrimPrefix(server.URL, "http://"), recorder.Body.String())
+}
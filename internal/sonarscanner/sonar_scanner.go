@@ -1,41 +1,56 @@
 package sonarscanner
 
 import (
-	"bufio"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
-	"path"
-	"regexp"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/LowCostCustoms/sonar-scanner-action/internal/sonarapi"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/gjson"
 )
 
+// ScannerCliLogPrefix is the "prefix" field value of the log entries relaying
+// the sonar-scanner cli output.
+const ScannerCliLogPrefix = "sonar-scanner-cli"
+
 var QualityGateWaitTimeout = errors.New("quality gate wait timeout")
 var AnalysisStatusWaitTimeout = errors.New("analysis status wait timeout")
 
+// AnalysisTaskNotFinished is returned by CheckProjectAnalysisStatus when the
+// analysis task is still pending or in progress.
+var AnalysisTaskNotFinished = errors.New("analysis task hasn't finished yet")
+
 const (
 	defaultWaitTimeout         = 2 * time.Second
 	defaultRequestTimeout      = 5 * time.Second
 	defaultMetadataFileName    = "report-task.txt"
-	defaultScannerWorkingDir   = "/opt/sonar-scanner-action/"
+	defaultScannerWorkingDir   = ".scannerwork"
 	defaultProjectFileLocation = "sonar-project.properties"
-	proxyListenAddr            = "localhost:6969"
+	defaultProxyListenAddr     = "localhost:0"
 )
 
 type RunFactory struct {
-	SonarHostUrl         string
-	SonarHostCert        string
+	SonarHostUrl           string
+	SonarHostCert          string
+	SonarClientCert        string
+	SonarClientKey         string
+	SonarClientKeyPassword string
+	// ScannerPath is either the sonar-scanner executable or its installation
+	// directory, sonar-scanner is looked up in the PATH if it's empty.
+	ScannerPath string
+	// ScannerWorkingDir is the sonar-scanner working directory, which the
+	// analysis report metadata file is written to.
 	ScannerWorkingDir    string
 	TlsSkipVerify        bool
 	MetadataFileName     string
@@ -43,29 +58,73 @@ type RunFactory struct {
 	SonarLogin           string
 	SonarPassword        string
 	ScannerVerboseOutput bool
-	LogEntry             *logrus.Entry
+	BranchParameters     *BranchParameters
+	ExtraArgs            string
+	HttpProxy            string
+	// ProxyListenAddr is the address the sonar host proxy listens on, a free
+	// localhost port is picked if it's empty. It must be a loopback address if
+	// the credentials or the client certificate are set.
+	ProxyListenAddr string
+	// ProxyToken is the token the sonar host proxy clients authenticate with,
+	// a random one is generated if it's empty.
+	ProxyToken   string
+	PollStrategy PollStrategy
+	RetryPolicy  *sonarapi.RetryPolicy
+	// ReportTaskFile, the report-task.txt file path, or CeTaskId identify an
+	// analysis made by some other tool, e.g. a Maven or Gradle plugin, when
+	// the run only waits for its results rather than runs sonar-scanner.
+	ReportTaskFile string
+	CeTaskId       string
+	LogEntry       *logrus.Entry
 }
 
 type Run struct {
 	sonarHostUrl         string
+	scannerPath          string
 	scannerWorkingDir    string
 	metadataFilePath     string
 	projectFileLocation  string
 	sonarLogin           string
 	sonarPassword        string
 	scannerVerboseOutput bool
+	branchParameters     *BranchParameters
+	extraArgs            []string
 	tlsConfig            *tls.Config
+	httpProxy            httpProxyFunc
+	httpProxyPasswords   []string
+	proxyListenAddr      string
+	pollStrategy         PollStrategy
+	retryPolicy          *sonarapi.RetryPolicy
+	reportTask           *ReportTask
+	proxyAddr            string
+	proxyToken           string
 	log                  *logrus.Entry
 }
 
 type ProjectAnalysisStatus struct {
-	TaskStatus     TaskStatus
-	AnalysisStatus AnalysisStatus
+	AnalysisId        string
+	TaskStatus        TaskStatus
+	TaskDetails       TaskDetails
+	AnalysisStatus    AnalysisStatus
+	QualityGateReport QualityGateReport
+}
+
+// TaskDetails describes how the analysis task has been processed. The error
+// message and stacktrace are set only for a failed task, while the warnings
+// may be reported for a successful one as well.
+type TaskDetails struct {
+	SubmittedAt     time.Time
+	ExecutionTime   time.Duration
+	ErrorMessage    string
+	ErrorStacktrace string
+	Warnings        []string
 }
 
 type taskStatusResponse struct {
-	analysisId string
-	taskStatus TaskStatus
+	analysisId  string
+	taskStatus  TaskStatus
+	taskDetails TaskDetails
+	retryAfter  time.Duration
 }
 
 type analysisStatusResponse struct {
@@ -74,8 +133,9 @@ type analysisStatusResponse struct {
 
 var undefinedResponse = taskStatusResponse{taskStatus: TaskStatusUndefined}
 var undefinedAnalysisStatus = ProjectAnalysisStatus{
-	TaskStatus:     TaskStatusUndefined,
-	AnalysisStatus: AnalysisStatusUndefined,
+	TaskStatus:        TaskStatusUndefined,
+	AnalysisStatus:    AnalysisStatusUndefined,
+	QualityGateReport: undefinedQualityGateReport,
 }
 
 func (c *RunFactory) NewRun() (*Run, error) {
@@ -94,25 +154,85 @@ func (c *RunFactory) NewRun() (*Run, error) {
 		projectFileLocation = defaultProjectFileLocation
 	}
 
-	props, err := c.getProjectProperties()
+	reportTask, err := c.getReportTask()
 	if err != nil {
 		return nil, err
 	}
 
+	props, err := c.getProjectProperties(reportTask)
+	if err != nil {
+		return nil, err
+	}
+
+	if reportTask != nil {
+		reportTask = reportTask.rebase(props.sonarHostUrl)
+	}
+
 	tlsConfig, err := c.getTlsClientConfig()
 	if err != nil {
 		return nil, err
 	}
 
+	extraArgs, err := parseExtraArgs(c.ExtraArgs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extra sonar-scanner arguments: %s", err)
+	}
+
+	httpProxy, err := getHttpProxyFunc(c.HttpProxy)
+	if err != nil {
+		return nil, err
+	}
+
+	pollStrategy := c.PollStrategy
+	if pollStrategy == nil {
+		pollStrategy = defaultPollStrategy
+	}
+
+	retryPolicy := c.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = defaultRetryPolicy
+	}
+
+	proxyListenAddr := c.ProxyListenAddr
+	if proxyListenAddr == "" {
+		proxyListenAddr = defaultProxyListenAddr
+	}
+
+	// The proxy token is sent in plain text, so the proxy adding the
+	// credentials must not be reachable from the other hosts.
+	if props.login != "" || c.SonarClientCert != "" {
+		if err := validateLoopbackAddr(proxyListenAddr); err != nil {
+			return nil, fmt.Errorf("invalid sonar host proxy listen address: %s", err)
+		}
+	}
+
+	proxyToken := c.ProxyToken
+	if proxyToken == "" {
+		proxyToken, err = newProxyToken()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the sonar host proxy token: %s", err)
+		}
+	}
+
 	return &Run{
 		sonarHostUrl:         props.sonarHostUrl,
-		scannerWorkingDir:    c.ScannerWorkingDir,
-		metadataFilePath:     path.Join(scannerWorkingDir, metadataFileName),
+		scannerPath:          c.ScannerPath,
+		scannerWorkingDir:    scannerWorkingDir,
+		metadataFilePath:     filepath.Join(scannerWorkingDir, metadataFileName),
 		projectFileLocation:  projectFileLocation,
 		tlsConfig:            tlsConfig,
+		httpProxy:            httpProxy,
+		httpProxyPasswords:   getHttpProxyPasswords(c.HttpProxy),
+		proxyListenAddr:      proxyListenAddr,
+		proxyToken:           proxyToken,
+		pollStrategy:         pollStrategy,
+		retryPolicy:          retryPolicy,
+		reportTask:           reportTask,
 		sonarLogin:           props.login,
 		sonarPassword:        props.password,
 		scannerVerboseOutput: c.ScannerVerboseOutput,
+		branchParameters:     c.BranchParameters,
+		extraArgs:            extraArgs,
 		log:                  c.LogEntry,
 	}, nil
 }
@@ -129,13 +249,52 @@ func (c *RunFactory) getTlsClientConfig() (*tls.Config, error) {
 		}
 	}
 
-	return &tls.Config{
+	config := &tls.Config{
 		InsecureSkipVerify: c.TlsSkipVerify,
 		RootCAs:            certPool,
-	}, nil
+	}
+
+	if c.SonarClientCert != "" {
+		certificate, err := loadClientCertificate(c.LogEntry, c.SonarClientCert, c.SonarClientKey, c.SonarClientKeyPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		config.Certificates = []tls.Certificate{*certificate}
+	}
+
+	return config, nil
 }
 
-func (c *RunFactory) getProjectProperties() (*projectProperties, error) {
+// getReportTask returns the metadata of an analysis made by some other tool, if
+// any.
+func (c *RunFactory) getReportTask() (*ReportTask, error) {
+	if c.ReportTaskFile != "" && c.CeTaskId != "" {
+		return nil, fmt.Errorf("either the report task file or the task id must be given, not both")
+	}
+
+	if c.CeTaskId != "" {
+		return &ReportTask{CeTaskId: c.CeTaskId}, nil
+	}
+
+	if c.ReportTaskFile != "" {
+		c.LogEntry.Debugf("Reading report task from %s", c.ReportTaskFile)
+
+		reportTask, err := readReportTaskFromFile(c.ReportTaskFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the report task file: %s", err)
+		}
+
+		return reportTask, nil
+	}
+
+	return nil, nil
+}
+
+// getProjectProperties returns the sonar host url and credentials, preferring
+// the factory settings over the project file and the project file over the
+// report task server url.
+func (c *RunFactory) getProjectProperties(reportTask *ReportTask) (*projectProperties, error) {
 	props := &projectProperties{
 		sonarHostUrl: c.SonarHostUrl,
 		login:        c.SonarLogin,
@@ -172,90 +331,269 @@ func (c *RunFactory) getProjectProperties() (*projectProperties, error) {
 		}
 	}
 
+	if props.sonarHostUrl == "" && reportTask != nil {
+		c.LogEntry.Debugf("Using sonar scanner host from the report task")
+
+		props.sonarHostUrl = reportTask.ServerUrl
+	}
+
 	if props.sonarHostUrl == "" {
 		return nil, fmt.Errorf("could not infer the sonar host url")
 	}
 
+	if err := validateSonarHostUrl(props.sonarHostUrl); err != nil {
+		return nil, err
+	}
+
 	return props, nil
 }
 
-func (r *Run) RunScanner(ctx context.Context) error {
+// validateLoopbackAddr makes sure the listen address is bound to a loopback
+// interface, the host must be either "localhost" or a loopback ip address.
+func validateLoopbackAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+
+	return fmt.Errorf("%s isn't a loopback address, which is required when the credentials or the client certificate are set", addr)
+}
+
+// validateSonarHostUrl makes sure the sonar host url is an absolute http or
+// https url, so that a malformed one is reported as a configuration error
+// rather than a failure of the sonar host proxy or the api requests.
+func validateSonarHostUrl(value string) error {
+	sonarHostUrl, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid sonar host url: %s", err)
+	}
+
+	if sonarHostUrl.Scheme != "http" && sonarHostUrl.Scheme != "https" {
+		return fmt.Errorf("invalid sonar host url: unsupported scheme '%s'", sonarHostUrl.Scheme)
+	}
+
+	if sonarHostUrl.Host == "" {
+		return fmt.Errorf("invalid sonar host url: missing host")
+	}
+
+	return nil
+}
+
+func (r *Run) RunScanner(ctx context.Context) (*ReportTask, error) {
+	scannerPath, err := findScanner(r.scannerPath)
+	if err != nil {
+		return nil, &ScannerError{Err: err}
+	}
+
+	r.log.Debugf("Using sonar-scanner %s", scannerPath)
+
+	proxy, err := r.listenReverseProxy()
+	if err != nil {
+		return nil, err
+	}
+
 	proxyCtx, proxyCtxCancel := context.WithCancel(ctx)
-	defer proxyCtxCancel()
+	proxyDone := make(chan struct{})
+	go func() {
+		defer close(proxyDone)
+
+		if err := proxy.serveWithContext(proxyCtx); err != nil {
+			r.log.Errorf("Sonar host proxy failed: %s", err)
+		}
+	}()
 
-	if err := r.runReverseProxy(proxyCtx); err != nil {
+	defer func() {
+		proxyCtxCancel()
+		<-proxyDone
+	}()
+
+	cmd := exec.CommandContext(ctx, scannerPath, r.getSonarScannerArgs()...)
+	cmd.Env = append(os.Environ(), r.getSonarScannerEnv()...)
+
+	if err := runSonarScanner(r.log.WithField("prefix", ScannerCliLogPrefix), cmd); err != nil {
+		return nil, &ScannerError{Err: err}
+	}
+
+	// The report task of an existing analysis, if any, is replaced with the
+	// one sonar-scanner has just submitted.
+	r.reportTask = nil
+	reportTask, err := r.ReportTask()
+	if err != nil {
+		return nil, &ScannerError{Err: fmt.Errorf("failed to read the analysis report metadata: %s", err)}
+	}
+
+	return reportTask, nil
+}
+
+// ServeProxy starts the sonar host proxy and serves the requests until the
+// context is done, so that the tools other than sonar-scanner can use it.
+func (r *Run) ServeProxy(ctx context.Context) error {
+	proxy, err := r.listenReverseProxy()
+	if err != nil {
 		return err
 	}
 
-	cmd := exec.CommandContext(ctx, "sonar-scanner", r.getSonarScannerArgs()...)
+	if err := proxy.serveWithContext(ctx); err != nil {
+		return &ProxyError{Err: err}
+	}
 
-	return runSonarScanner(r.log.WithField("prefix", "sonar-scanner-cli"), cmd)
+	return nil
 }
 
+// RetrieveProjectanalysisStatus waits for the analysis task to finish and
+// returns its status along with the quality gate status.
 func (r *Run) RetrieveProjectanalysisStatus(ctx context.Context) (ProjectAnalysisStatus, error) {
-	status := ProjectAnalysisStatus{
-		TaskStatus:     TaskStatusUndefined,
-		AnalysisStatus: AnalysisStatusUndefined,
-	}
+	return r.getProjectAnalysisStatus(ctx, true)
+}
 
-	r.log.Infof("Using metadata file %s", r.metadataFilePath)
+// CheckProjectAnalysisStatus returns the status of an analysis task that has
+// already finished along with the quality gate status, AnalysisTaskNotFinished
+// is returned if it hasn't.
+func (r *Run) CheckProjectAnalysisStatus(ctx context.Context) (ProjectAnalysisStatus, error) {
+	return r.getProjectAnalysisStatus(ctx, false)
+}
 
-	url, err := getTaskUrlFromFile(r.metadataFilePath)
+func (r *Run) getProjectAnalysisStatus(ctx context.Context, wait bool) (ProjectAnalysisStatus, error) {
+	status := undefinedAnalysisStatus
+
+	reportTask, err := r.ReportTask()
 	if err != nil {
 		return undefinedAnalysisStatus, err
 	}
 
-	r.log.Infof("Using task result url %s", url)
+	r.log.Infof("Using analysis task %s", reportTask.CeTaskId)
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: r.tlsConfig,
-		},
-		Timeout: defaultRequestTimeout,
+	client, err := r.newApiClient()
+	if err != nil {
+		return status, err
 	}
 
 	r.log.Infof("Retrieving analysis task status")
 
-	taskStatus, err := r.retrieveTaskStatus(ctx, client, url)
+	taskStatus, err := r.retrieveTaskStatus(ctx, client, reportTask.CeTaskId, wait)
 	if err != nil {
 		return status, err
 	}
 
+	status.AnalysisId = taskStatus.analysisId
 	status.TaskStatus = taskStatus.taskStatus
+	status.TaskDetails = taskStatus.taskDetails
+
 	if status.TaskStatus != TaskStatusSuccess {
 		return status, nil
 	}
 
 	r.log.Infof("Retrieving quality gate status")
 
-	analysisStatus, err := r.retrieveProjectAnalysisStatus(ctx, client, taskStatus.analysisId)
+	report, err := r.retrieveProjectAnalysisStatus(ctx, client, taskStatus.analysisId)
 	if err != nil {
 		return status, err
 	}
 
-	status.AnalysisStatus = analysisStatus
+	status.AnalysisStatus = report.Status
+	status.QualityGateReport = report
 	return status, nil
 }
 
-func (r *Run) runReverseProxy(ctx context.Context) error {
+// Secrets returns the credentials the run uses, including the ones read from
+// the project file, along with the basic auth credentials made of them, the
+// sonar host proxy token and the http proxy passwords.
+func (r *Run) Secrets() []string {
+	var secrets []string
+	if r.sonarLogin != "" {
+		secrets = append(secrets, r.sonarLogin, fmt.Sprintf("%s:%s", r.sonarLogin, r.sonarPassword))
+	}
+
+	if r.sonarPassword != "" {
+		secrets = append(secrets, r.sonarPassword)
+	}
+
+	if r.proxyToken != "" {
+		secrets = append(secrets, r.proxyToken)
+	}
+
+	return append(secrets, r.httpProxyPasswords...)
+}
+
+// newApiClient creates a SonarQube Web API client authenticated with the run
+// credentials.
+func (r *Run) newApiClient() (*sonarapi.Client, error) {
+	factory := &sonarapi.ClientFactory{
+		BaseUrl:  r.sonarHostUrl,
+		Login:    r.sonarLogin,
+		Password: r.sonarPassword,
+		HttpClient: &http.Client{
+			Transport: newHttpTransport(r.tlsConfig, r.httpProxy),
+			Timeout:   defaultRequestTimeout,
+		},
+		RetryPolicy: r.retryPolicy,
+		LogEntry:    r.log.WithField("prefix", "sonar-api"),
+	}
+
+	return factory.NewClient()
+}
+
+// ReportTask returns the metadata of the submitted analysis report, reading it
+// from the metadata file on the first call.
+func (r *Run) ReportTask() (*ReportTask, error) {
+	if r.reportTask != nil {
+		return r.reportTask, nil
+	}
+
+	r.log.Infof("Using metadata file %s", r.metadataFilePath)
+
+	reportTask, err := readReportTaskFromFile(r.metadataFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	r.reportTask = reportTask.rebase(r.sonarHostUrl)
+	return r.reportTask, nil
+}
+
+// listenReverseProxy creates a sonar host proxy and binds it to a free port,
+// so that sonar-scanner can be pointed to it.
+func (r *Run) listenReverseProxy() (*sonarHostProxy, error) {
 	proxyFactory := &sonarHostProxyFactory{
-		listenAddr:   proxyListenAddr,
+		listenAddr:   r.proxyListenAddr,
 		config:       r.tlsConfig,
+		httpProxy:    r.httpProxy,
 		log:          r.log.WithField("prefix", "sonar-host-proxy"),
 		sonarHostUrl: r.sonarHostUrl,
+		token:        r.proxyToken,
+		login:        r.sonarLogin,
+		password:     r.sonarPassword,
 	}
+	// The sonar host url is validated by NewRun, so a failure here isn't a
+	// proxy start failure.
 	proxy, err := proxyFactory.new()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	go func() {
-		if err := proxy.serveWithContext(ctx); err != nil {
-			r.log.Errorf("Failed to start a sonar host proxy: %s", err)
-		}
-	}()
+	addr, err := proxy.listen()
+	if err != nil {
+		return nil, &ProxyError{Err: err}
+	}
 
-	return nil
+	r.proxyAddr = addr
+	return proxy, nil
+}
+
+// getSonarScannerEnv returns the environment variables passing the proxy token
+// to sonar-scanner as the sonar.login property, which unlike the command line
+// arguments can't be seen by the other users.
+func (r *Run) getSonarScannerEnv() []string {
+	params, _ := json.Marshal(map[string]string{"sonar.login": r.proxyToken})
+	return []string{fmt.Sprintf("SONARQUBE_SCANNER_PARAMS=%s", params)}
 }
 
 func (r *Run) getSonarScannerArgs() []string {
@@ -264,8 +602,8 @@ func (r *Run) getSonarScannerArgs() []string {
 
 	args := []string{
 		fmt.Sprintf("-Dsonar.working.directory=%s", r.scannerWorkingDir),
-		fmt.Sprintf("-Dsonar.scanner.metadataFilePath=%s", path.Join(r.scannerWorkingDir, r.metadataFilePath)),
-		fmt.Sprintf("-Dsonar.host.url=http://%s", proxyListenAddr),
+		fmt.Sprintf("-Dsonar.scanner.metadataFilePath=%s", r.metadataFilePath),
+		fmt.Sprintf("-Dsonar.host.url=http://%s", r.proxyAddr),
 	}
 
 	if r.projectFileLocation != "" {
@@ -274,12 +612,11 @@ func (r *Run) getSonarScannerArgs() []string {
 		args = append(args, fmt.Sprintf("-Dproject.settings=%s", r.projectFileLocation))
 	}
 
-	if r.sonarLogin != "" {
-		args = append(args, fmt.Sprintf("-Dsonar.login=%s", r.sonarLogin))
+	if r.branchParameters != nil {
+		branchArgs := r.branchParameters.args()
+		r.log.Debugf("Sonar-Scanner cli branch parameters: %s", strings.Join(branchArgs, " "))
 
-		if r.sonarPassword != "" {
-			args = append(args, fmt.Sprintf("-Dsonar.password=%s", r.sonarPassword))
-		}
+		args = append(args, branchArgs...)
 	}
 
 	if r.scannerVerboseOutput {
@@ -288,14 +625,26 @@ func (r *Run) getSonarScannerArgs() []string {
 		args = append(args, "-X")
 	}
 
+	if len(r.extraArgs) != 0 {
+		r.log.Debugf("Sonar-Scanner cli extra arguments: %s", strings.Join(r.extraArgs, " "))
+
+		args = append(args, r.extraArgs...)
+	}
+
 	return args
 }
 
-func (r *Run) retrieveTaskStatus(ctx context.Context, client *http.Client, url string) (taskStatusResponse, error) {
+func (r *Run) retrieveTaskStatus(
+	ctx context.Context,
+	client *sonarapi.Client,
+	taskId string,
+	wait bool,
+) (taskStatusResponse, error) {
+	polls := 0
 	for {
 		r.log.Debugf("Reading task status from the server")
 
-		response, err := r.requestTaskStatus(ctx, client, url)
+		response, err := r.requestTaskStatus(ctx, client, taskId)
 		if err != nil {
 			return undefinedResponse, err
 		}
@@ -303,14 +652,20 @@ func (r *Run) retrieveTaskStatus(ctx context.Context, client *http.Client, url s
 		taskStatus := response.taskStatus
 		r.log.Debugf("Task status returned in the response was '%s'", taskStatus)
 
-		if taskStatus == TaskStatusSuccess || taskStatus == TaskStatusCancelled || taskStatus == TaskStatusUndefined {
+		if taskStatus.isFinal() {
 			return response, nil
 		}
 
-		r.log.Debugf("Waiting for %s before next poll", defaultWaitTimeout)
+		if !wait {
+			return undefinedResponse, AnalysisTaskNotFinished
+		}
+
+		polls++
+		delay := nextPollDelay(r.pollStrategy, polls, response.retryAfter)
+		r.log.Debugf("Waiting for %s before next poll", delay)
 
 		select {
-		case <-time.After(defaultWaitTimeout):
+		case <-time.After(delay):
 			continue
 		case <-ctx.Done():
 			return undefinedResponse, QualityGateWaitTimeout
@@ -318,131 +673,86 @@ func (r *Run) retrieveTaskStatus(ctx context.Context, client *http.Client, url s
 	}
 }
 
-func (r *Run) makeSonarServerRequest(
+func (r *Run) requestTaskStatus(
 	ctx context.Context,
-	client *http.Client,
-	method string,
-	url string,
-) (*gjson.Result, error) {
-	request, err := http.NewRequestWithContext(ctx, method, url, nil)
+	client *sonarapi.Client,
+	taskId string,
+) (taskStatusResponse, error) {
+	task, err := client.Task(ctx, taskId)
 	if err != nil {
-		return nil, err
-	}
-
-	if r.sonarLogin != "" {
-		r.log.Debugf("Using basic auth for request %s", url)
-
-		request.SetBasicAuth(r.sonarLogin, r.sonarPassword)
-	}
-
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, err
-	}
-
-	defer response.Body.Close()
-
-	return processResponse(response)
-}
-
-func (r *Run) requestTaskStatus(ctx context.Context, client *http.Client, url string) (taskStatusResponse, error) {
-	response, err := r.makeSonarServerRequest(ctx, client, "GET", url)
-	if err != nil {
-		return undefinedResponse, err
-	}
-
-	if err != nil {
-		if err == context.Canceled {
+		if isContextDone(ctx, err) {
 			return undefinedResponse, QualityGateWaitTimeout
 		}
 
 		return undefinedResponse, err
 	}
 
-	taskStatus, err := parseTaskStatus(response.Get("task.status").Str)
+	taskStatus, err := parseTaskStatus(task.Status)
 	if err != nil {
 		return undefinedResponse, err
 	}
 
 	return taskStatusResponse{
-		analysisId: response.Get("task.analysisId").Str,
-		taskStatus: taskStatus,
+		analysisId:  task.AnalysisId,
+		taskStatus:  taskStatus,
+		taskDetails: r.getTaskDetails(task),
+		retryAfter:  task.RetryAfter,
 	}, nil
 }
 
-func (r *Run) retrieveProjectAnalysisStatus(
-	ctx context.Context,
-	client *http.Client,
-	analysisId string,
-) (AnalysisStatus, error) {
-	url := getApiUrl(r.sonarHostUrl, fmt.Sprintf("/api/qualitygates/project_status?analysisId=%s", analysisId))
-	r.log.Debugf("Reading analysis status from %s", url)
-
-	response, err := r.makeSonarServerRequest(ctx, client, "GET", url)
-	if err != nil {
-		if err == context.Canceled {
-			return AnalysisStatusUndefined, AnalysisStatusWaitTimeout
-		}
-
-		return AnalysisStatusUndefined, err
+// isContextDone tells whether a request failed because the context is done,
+// unlike a request exceeding the http client timeout, which fails with an
+// error matching context.DeadlineExceeded as well.
+func isContextDone(ctx context.Context, err error) bool {
+	if ctx.Err() == nil {
+		return false
 	}
 
-	status, err := parseAnalysisStatus(response.Get("projectStatus.status").Str)
-	if err != nil {
-		return AnalysisStatusUndefined, err
-	}
-
-	r.log.Debugf("Analysis status returned in response was '%s'", status)
-
-	return status, nil
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
 }
 
-func processResponse(response *http.Response) (*gjson.Result, error) {
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("server returned response with code %d", response.StatusCode)
+func (r *Run) getTaskDetails(task *sonarapi.Task) TaskDetails {
+	details := TaskDetails{
+		ExecutionTime:   time.Duration(task.ExecutionTimeMs) * time.Millisecond,
+		ErrorMessage:    task.ErrorMessage,
+		ErrorStacktrace: task.ErrorStacktrace,
+		Warnings:        task.Warnings,
 	}
 
-	contentType := response.Header.Get("content-type")
-	if contentType != "application/json" {
-		return nil, fmt.Errorf("unexpected response content-type '%s'", contentType)
-	}
+	if task.SubmittedAt != "" {
+		submittedAt, err := sonarapi.ParseDateTime(task.SubmittedAt)
+		if err != nil {
+			r.log.Debugf("Failed to parse the task submission time: %s", err)
+		}
 
-	responseBody, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+		details.SubmittedAt = submittedAt
 	}
 
-	responseJSON := gjson.ParseBytes(responseBody)
-	return &responseJSON, nil
+	return details
 }
 
-func getTaskUrlFromFile(fileName string) (string, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return "", err
-	}
-
-	defer file.Close()
+func (r *Run) retrieveProjectAnalysisStatus(
+	ctx context.Context,
+	client *sonarapi.Client,
+	analysisId string,
+) (QualityGateReport, error) {
+	r.log.Debugf("Reading analysis status of the analysis %s", analysisId)
 
-	return getTaskUrl(file)
-}
+	projectStatus, err := client.ProjectStatus(ctx, analysisId)
+	if err != nil {
+		if isContextDone(ctx, err) {
+			return undefinedQualityGateReport, AnalysisStatusWaitTimeout
+		}
 
-func getTaskUrl(reader io.Reader) (string, error) {
-	rx, _ := regexp.Compile("^\\s*ceTaskUrl\\s*=\\s*(.*)\\s*$")
+		return undefinedQualityGateReport, err
+	}
 
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		matches := rx.FindStringSubmatch(scanner.Text())
-		if matches != nil {
-			return matches[1], nil
-		}
+	report, err := parseQualityGateReport(projectStatus)
+	if err != nil {
+		return undefinedQualityGateReport, err
 	}
 
-	return "", errors.New("metadata file doesn't contain task url")
-}
+	r.log.Debugf("Analysis status returned in response was '%s'", report.Status)
 
-func getApiUrl(host, endpoint string) string {
-	host = strings.TrimSuffix(host, "/")
-	endpoint = strings.TrimPrefix(endpoint, "/")
-	return fmt.Sprintf("%s/%s", host, endpoint)
+	return report, nil
 }
@@ -1,57 +1,21 @@
 package sonarscanner
 
 import (
+	"context"
 	"fmt"
-	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
-	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
-type testReader struct {
-	reader io.Reader
-}
-
-func (reader *testReader) Read(p []byte) (int, error) {
-	return reader.reader.Read(p)
-}
-
-func (reader *testReader) Close() error {
-	return nil
-}
-
-func TestGetTaskUrlSucceeds(t *testing.T) {
-	reader := strings.NewReader(
-		`
-        property=1
-        property2=2
-        ceTaskUrl  = some url
-        `,
-	)
-	url, err := getTaskUrl(reader)
-
-	assert.Nil(t, err)
-	assert.Equal(t, url, "some url")
-}
-
-func TestGetTaskUrlFails(t *testing.T) {
-	reader := strings.NewReader(
-		`
-        property=1
-        property2=2
-        `,
-	)
-	url, err := getTaskUrl(reader)
-
-	assert.NotNil(t, err)
-	assert.Equal(t, url, "")
-}
-
 func TestStatusNameFormat(t *testing.T) {
 	assert.Equal(t, fmt.Sprint(TaskStatusUndefined), "UNDEFINED")
 	assert.Equal(t, fmt.Sprint(TaskStatusPending), "PENDING")
@@ -61,137 +25,134 @@ func TestStatusNameFormat(t *testing.T) {
 	assert.Equal(t, fmt.Sprint(TaskStatusFailed), "FAILED")
 }
 
-func TestProcessTaskStatusResponse(t *testing.T) {
-	response := &http.Response{
-		StatusCode: 200,
-		Body: &testReader{
-			reader: strings.NewReader(
-				`
-                {
-                    "message": "ok"
-                }
-                `,
-			),
-		},
-		Header: http.Header{
-			"Content-Type": {"application/json"},
-		},
+func TestNewRun(t *testing.T) {
+	factory := RunFactory{
+		SonarHostUrl:         "http://localhost",
+		SonarHostCert:        "",
+		SonarLogin:           "sonar-login",
+		SonarPassword:        "sonar-password",
+		ProjectFileLocation:  "sonar-project.properties",
+		MetadataFileName:     "metadata-file-name",
+		ScannerPath:          "/opt/sonar-scanner",
+		ScannerWorkingDir:    "/opt/",
+		ScannerVerboseOutput: true,
+		LogEntry:             logrus.NewEntry(logrus.New()),
 	}
 
-	json, err := processResponse(response)
+	run, err := factory.NewRun()
 
 	assert.Nil(t, err)
-	assert.Equal(t, "ok", json.Get("message").Str)
+	assert.NotNil(t, run)
+	assert.Equal(t, run.sonarHostUrl, "http://localhost")
+	assert.Equal(t, run.sonarLogin, "sonar-login")
+	assert.Equal(t, run.sonarPassword, "sonar-password")
+	assert.Equal(t, run.scannerPath, "/opt/sonar-scanner")
+	assert.Equal(t, run.scannerWorkingDir, "/opt/")
+	assert.Equal(t, run.metadataFilePath, "/opt/metadata-file-name")
+	assert.Equal(t, run.projectFileLocation, "sonar-project.properties")
+	assert.Equal(t, run.scannerVerboseOutput, true)
 }
 
-func TestProcessTaskStatusResponseWithInvalidStatus(t *testing.T) {
-	response := &http.Response{
-		StatusCode: 400,
-		Body: &testReader{
-			reader: strings.NewReader(
-				`
-                {
-                    "message": "not ok"
-                }
-                `,
-			),
-		},
-		Header: http.Header{
-			"Content-Type": {"application/json"},
-		},
+func TestNewRunDefaultScannerWorkingDir(t *testing.T) {
+	factory := RunFactory{
+		SonarHostUrl: "http://localhost",
+		LogEntry:     logrus.NewEntry(logrus.New()),
 	}
 
-	_, err := processResponse(response)
+	run, err := factory.NewRun()
 
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
+	assert.Equal(t, "", run.scannerPath)
+	assert.Equal(t, ".scannerwork", run.scannerWorkingDir)
+	assert.Equal(t, path.Join(".scannerwork", "report-task.txt"), run.metadataFilePath)
 }
 
-func TestProcessTaskStatusResponseWithInvalidContentType(t *testing.T) {
-	response := &http.Response{
-		StatusCode: 200,
-		Body: &testReader{
-			reader: strings.NewReader("<html></html>"),
-		},
-		Header: http.Header{
-			"Content-Type": {"text/html"},
-		},
+func TestNewRunWithCeTaskId(t *testing.T) {
+	factory := RunFactory{
+		SonarHostUrl: "http://localhost",
+		CeTaskId:     "task-id",
+		LogEntry:     logrus.NewEntry(logrus.New()),
 	}
 
-	_, err := processResponse(response)
+	run, err := factory.NewRun()
 
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
+	assert.Equal(t, &ReportTask{CeTaskId: "task-id"}, run.reportTask)
 }
 
-func TestGetTaskUrlFromFile(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataFileName := path.Join(tempDir, "report-task.txt")
+func TestNewRunWithReportTaskFile(t *testing.T) {
+	reportTaskFile := path.Join(t.TempDir(), "report-task.txt")
+	ioutil.WriteFile(reportTaskFile, []byte(`
+projectKey=project
+serverUrl=http://sonarqube.local
+dashboardUrl=http://sonarqube.local/dashboard?id=project
+ceTaskId=task-id
+ceTaskUrl=http://sonarqube.local/api/ce/task?id=task-id
+`), 0644)
 
-	file, _ := os.Create(metadataFileName)
-	file.WriteString("ceTaskUrl=http://poll-me/?q=1")
-	file.Close()
+	factory := RunFactory{
+		ReportTaskFile: reportTaskFile,
+		LogEntry:       logrus.NewEntry(logrus.New()),
+	}
 
-	url, err := getTaskUrlFromFile(metadataFileName)
+	run, err := factory.NewRun()
 
 	assert.Nil(t, err)
-	assert.Equal(t, url, "http://poll-me/?q=1")
-}
-
-func TestGetTaskUrlFromFileInvalidFile(t *testing.T) {
-	tempDir := t.TempDir()
-	metadataFileName := path.Join(tempDir, "nonexistent-report-task.txt")
+	assert.Equal(t, "http://sonarqube.local", run.sonarHostUrl)
 
-	url, err := getTaskUrlFromFile(metadataFileName)
+	reportTask, err := run.ReportTask()
 
-	assert.NotNil(t, err)
-	assert.Equal(t, url, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "task-id", reportTask.CeTaskId)
+	assert.Equal(t, "project", reportTask.ProjectKey)
 }
 
-func TestGetTaskUrl(t *testing.T) {
-	reader := strings.NewReader(`
-	invalid=invalid
-	invalid2=invalid2
-	ceTaskUrl=url
-	ceTaskUrl_=url2
-	`)
+func TestNewRunWithReportTaskFileRebased(t *testing.T) {
+	reportTaskFile := path.Join(t.TempDir(), "report-task.txt")
+	ioutil.WriteFile(reportTaskFile, []byte(`
+serverUrl=http://internal:9000
+dashboardUrl=http://internal:9000/dashboard?id=project
+ceTaskId=task-id
+ceTaskUrl=http://internal:9000/api/ce/task?id=task-id
+`), 0644)
 
-	url, err := getTaskUrl(reader)
+	factory := RunFactory{
+		SonarHostUrl:   "https://sonarqube.example.com",
+		ReportTaskFile: reportTaskFile,
+		LogEntry:       logrus.NewEntry(logrus.New()),
+	}
+
+	run, err := factory.NewRun()
 
 	assert.Nil(t, err)
-	assert.Equal(t, url, "url")
+	assert.Equal(t, "https://sonarqube.example.com/dashboard?id=project", run.reportTask.DashboardUrl)
 }
 
-func TestGetTaskUrlBadReport(t *testing.T) {
-	reader := strings.NewReader("somethingElse=yikes")
+func TestNewRunWithInvalidReportTaskFile(t *testing.T) {
+	factory := RunFactory{
+		SonarHostUrl:   "http://localhost",
+		ReportTaskFile: path.Join(t.TempDir(), "report-task.txt"),
+		LogEntry:       logrus.NewEntry(logrus.New()),
+	}
 
-	url, err := getTaskUrl(reader)
+	run, err := factory.NewRun()
 
 	assert.NotNil(t, err)
-	assert.Equal(t, url, "")
+	assert.Nil(t, run)
 }
 
-func TestNewRun(t *testing.T) {
+func TestNewRunWithReportTaskFileAndCeTaskId(t *testing.T) {
 	factory := RunFactory{
-		SonarHostUrl:         "http://localhost",
-		SonarHostCert:        "",
-		SonarLogin:           "sonar-login",
-		SonarPassword:        "sonar-password",
-		ProjectFileLocation:  "sonar-project.properties",
-		MetadataFileName:     "metadata-file-name",
-		ScannerWorkingDir:    "/opt/",
-		ScannerVerboseOutput: true,
-		LogEntry:             logrus.NewEntry(logrus.New()),
+		SonarHostUrl:   "http://localhost",
+		ReportTaskFile: "report-task.txt",
+		CeTaskId:       "task-id",
+		LogEntry:       logrus.NewEntry(logrus.New()),
 	}
 
 	run, err := factory.NewRun()
 
-	assert.Nil(t, err)
-	assert.NotNil(t, run)
-	assert.Equal(t, run.sonarHostUrl, "http://localhost")
-	assert.Equal(t, run.sonarLogin, "sonar-login")
-	assert.Equal(t, run.sonarPassword, "sonar-password")
-	assert.Equal(t, run.scannerWorkingDir, "/opt/")
-	assert.Equal(t, run.projectFileLocation, "sonar-project.properties")
-	assert.Equal(t, run.scannerVerboseOutput, true)
+	assert.NotNil(t, err)
+	assert.Nil(t, run)
 }
 
 func TestNewRunWithProperties(t *testing.T) {
@@ -248,6 +209,33 @@ func TestNewRunPrefersFactorySettings(t *testing.T) {
 	assert.Equal(t, run.sonarPassword, "")
 }
 
+func TestNewRunWithExtraArgs(t *testing.T) {
+	factory := RunFactory{
+		SonarHostUrl: "http://localhost",
+		ExtraArgs:    "-Dsonar.projectVersion=1.0 -Dsonar.sources='src main'",
+		LogEntry:     logrus.NewEntry(logrus.New()),
+	}
+
+	run, err := factory.NewRun()
+
+	assert.Nil(t, err)
+	assert.NotNil(t, run)
+	assert.Equal(t, run.extraArgs, []string{"-Dsonar.projectVersion=1.0", "-Dsonar.sources=src main"})
+}
+
+func TestNewRunWithInvalidExtraArgs(t *testing.T) {
+	factory := RunFactory{
+		SonarHostUrl: "http://localhost",
+		ExtraArgs:    "-Dsonar.host.url=http://other-host",
+		LogEntry:     logrus.NewEntry(logrus.New()),
+	}
+
+	run, err := factory.NewRun()
+
+	assert.Nil(t, run)
+	assert.NotNil(t, err)
+}
+
 func TestNewRunWithInvalidSonarHostUrl(t *testing.T) {
 	tempDir := t.TempDir()
 	propertiesFileName := path.Join(tempDir, "sonar-project.properties")
@@ -270,6 +258,65 @@ func TestNewRunWithInvalidSonarHostUrl(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestNewRunWithMalformedSonarHostUrl(t *testing.T) {
+	for _, sonarHostUrl := range []string{"\x00\x01", "sonarqube.local", "ftp://sonarqube.local", "http://"} {
+		factory := RunFactory{
+			SonarHostUrl: sonarHostUrl,
+			LogEntry:     logrus.NewEntry(logrus.New()),
+		}
+
+		run, err := factory.NewRun()
+
+		assert.Nil(t, run, sonarHostUrl)
+		assert.NotNil(t, err, sonarHostUrl)
+	}
+}
+
+func TestNewRunWithProxyListenAddr(t *testing.T) {
+	for _, addr := range []string{"localhost:9000", "127.0.0.1:9000", "[::1]:9000"} {
+		factory := RunFactory{
+			SonarHostUrl:    "http://sonarqube.local",
+			SonarLogin:      "token",
+			ProxyListenAddr: addr,
+			LogEntry:        logrus.NewEntry(logrus.New()),
+		}
+
+		run, err := factory.NewRun()
+
+		if assert.Nil(t, err, addr) {
+			assert.Equal(t, addr, run.proxyListenAddr)
+		}
+	}
+}
+
+func TestNewRunWithNonLoopbackProxyListenAddr(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:9000", ":9000", "sonar-proxy.local:9000", "10.0.0.1:9000", "localhost"} {
+		factory := RunFactory{
+			SonarHostUrl:    "http://sonarqube.local",
+			SonarLogin:      "token",
+			ProxyListenAddr: addr,
+			LogEntry:        logrus.NewEntry(logrus.New()),
+		}
+
+		run, err := factory.NewRun()
+
+		assert.NotNil(t, err, addr)
+		assert.Nil(t, run, addr)
+	}
+
+	// Without the credentials the proxy grants no access the server doesn't.
+	factory := RunFactory{
+		SonarHostUrl:    "http://sonarqube.local",
+		ProxyListenAddr: "0.0.0.0:9000",
+		LogEntry:        logrus.NewEntry(logrus.New()),
+	}
+
+	run, err := factory.NewRun()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "0.0.0.0:9000", run.proxyListenAddr)
+}
+
 func TestGetSonarScannerArgs(t *testing.T) {
 	run := &Run{
 		scannerVerboseOutput: true,
@@ -277,25 +324,329 @@ func TestGetSonarScannerArgs(t *testing.T) {
 		sonarPassword:        "password1",
 		projectFileLocation:  "props",
 		scannerWorkingDir:    "/opt/",
-		metadataFilePath:     "mfp",
+		metadataFilePath:     "/opt/mfp",
 		sonarHostUrl:         "http://custom-url",
+		proxyAddr:            "localhost:6969",
 		log:                  logrus.NewEntry(logrus.New()),
 	}
 
 	args := run.getSonarScannerArgs()
 
-	assert.Equal(t, len(args), 7)
+	assert.Equal(t, len(args), 5)
 	assert.Contains(t, args, "-X")
-	assert.Contains(t, args, "-Dsonar.login=login1")
-	assert.Contains(t, args, "-Dsonar.password=password1")
+	assert.NotContains(t, args, "-Dsonar.login=login1")
+	assert.NotContains(t, args, "-Dsonar.password=password1")
 	assert.Contains(t, args, "-Dproject.settings=props")
 	assert.Contains(t, args, "-Dsonar.working.directory=/opt/")
 	assert.Contains(t, args, "-Dsonar.scanner.metadataFilePath=/opt/mfp")
 	assert.Contains(t, args, "-Dsonar.host.url=http://localhost:6969")
 }
 
-func TestGetApiUrl(t *testing.T) {
-	assert.Equal(t, "http://host/api/url/", getApiUrl("http://host/", "/api/url/"))
-	assert.Equal(t, "http://host/api/url", getApiUrl("http://host", "api/url"))
-	assert.Equal(t, "http://host/api/url", getApiUrl("http://host/", "api/url"))
+func TestGetSonarScannerArgsWithBranchParameters(t *testing.T) {
+	run := &Run{
+		scannerWorkingDir: "/opt/",
+		metadataFilePath:  "/opt/mfp",
+		sonarHostUrl:      "http://custom-url",
+		branchParameters: &BranchParameters{
+			PullRequestKey:    "42",
+			PullRequestBranch: "feature",
+			PullRequestBase:   "main",
+		},
+		extraArgs: []string{"-Dsonar.projectVersion=1.0"},
+		log:       logrus.NewEntry(logrus.New()),
+	}
+
+	args := run.getSonarScannerArgs()
+
+	assert.Equal(t, len(args), 7)
+	assert.Equal(t, args[6], "-Dsonar.projectVersion=1.0")
+	assert.Contains(t, args, "-Dsonar.pullrequest.key=42")
+	assert.Contains(t, args, "-Dsonar.pullrequest.branch=feature")
+	assert.Contains(t, args, "-Dsonar.pullrequest.base=main")
+}
+
+func TestGetSonarScannerEnv(t *testing.T) {
+	run := &Run{proxyToken: "proxy-token"}
+
+	assert.Equal(t, []string{`SONARQUBE_SCANNER_PARAMS={"sonar.login":"proxy-token"}`}, run.getSonarScannerEnv())
+}
+
+func TestRunSecrets(t *testing.T) {
+	run := &Run{
+		sonarLogin:         "login",
+		sonarPassword:      "password",
+		proxyToken:         "proxy-token",
+		httpProxyPasswords: []string{"proxy-password"},
+	}
+
+	assert.Equal(t, []string{"login", "login:password", "password", "proxy-token", "proxy-password"}, run.Secrets())
+	assert.Empty(t, (&Run{}).Secrets())
+}
+
+func TestRunScannerReadsSubmittedReportTask(t *testing.T) {
+	tempDir := t.TempDir()
+	metadataFilePath := path.Join(tempDir, "report-task.txt")
+	script := fmt.Sprintf("#!/bin/sh\nprintf 'serverUrl=http://sonarqube.local\\nceTaskId=new-task\\nceTaskUrl=http://sonarqube.local/api/ce/task?id=new-task\\n' > %s\n", metadataFilePath)
+	assert.Nil(t, ioutil.WriteFile(path.Join(tempDir, "sonar-scanner"), []byte(script), 0755))
+
+	originalPath := os.Getenv("PATH")
+	os.Setenv("PATH", tempDir)
+	defer os.Setenv("PATH", originalPath)
+
+	run := &Run{
+		sonarHostUrl:     "http://sonarqube.local",
+		metadataFilePath: metadataFilePath,
+		reportTask:       &ReportTask{CeTaskId: "old-task"},
+		log:              logrus.NewEntry(logrus.New()),
+	}
+
+	reportTask, err := run.RunScanner(context.Background())
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, "new-task", reportTask.CeTaskId)
+	}
+}
+
+func TestRunScannerStopsProxyOnFailure(t *testing.T) {
+	scannerPath := path.Join(t.TempDir(), "sonar-scanner")
+	assert.Nil(t, ioutil.WriteFile(scannerPath, []byte("#!/bin/sh\nexit 1\n"), 0755))
+
+	run := &Run{
+		sonarHostUrl: "http://sonarqube.local",
+		scannerPath:  scannerPath,
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	reportTask, err := run.RunScanner(context.Background())
+
+	assert.NotNil(t, err)
+	assert.Equal(t, ExitCodeScannerError, ExitCode(err))
+	assert.Nil(t, reportTask)
+	assert.NotEqual(t, "", run.proxyAddr)
+
+	_, err = http.Get(fmt.Sprintf("http://%s/", run.proxyAddr))
+	assert.NotNil(t, err)
+}
+
+func TestRunScannerNotFound(t *testing.T) {
+	originalPath := os.Getenv("PATH")
+	os.Setenv("PATH", t.TempDir())
+	defer os.Setenv("PATH", originalPath)
+
+	run := &Run{
+		sonarHostUrl: "http://sonarqube.local",
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	reportTask, err := run.RunScanner(context.Background())
+
+	assert.NotNil(t, err)
+	assert.Equal(t, ExitCodeScannerError, ExitCode(err))
+	assert.Nil(t, reportTask)
+	assert.Equal(t, "", run.proxyAddr)
+}
+
+func TestRetrieveTaskStatusPollsUntilFinalStatus(t *testing.T) {
+	statuses := []string{"PENDING", "IN_PROGRESS", "FAILED"}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(res, `{"task": {"status": "%s"}}`, statuses[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	run := &Run{
+		sonarHostUrl: server.URL,
+		pollStrategy: &FixedPollStrategy{Interval: time.Millisecond},
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	client, err := run.newApiClient()
+	assert.Nil(t, err)
+
+	response, err := run.retrieveTaskStatus(context.Background(), client, "task-id", true)
+
+	assert.Nil(t, err)
+	assert.Equal(t, TaskStatusFailed, response.taskStatus)
+	assert.Equal(t, 3, requests)
+}
+
+func TestRetrieveTaskStatusTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`{"task": {"status": "PENDING"}}`))
+	}))
+	defer server.Close()
+
+	run := &Run{
+		sonarHostUrl: server.URL,
+		pollStrategy: &FixedPollStrategy{Interval: time.Hour},
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	client, err := run.newApiClient()
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = run.retrieveTaskStatus(ctx, client, "task-id", true)
+
+	assert.Equal(t, QualityGateWaitTimeout, err)
+}
+
+func TestRetrieveTaskStatusRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	run := &Run{
+		sonarHostUrl: server.URL,
+		pollStrategy: &FixedPollStrategy{Interval: time.Millisecond},
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	client, err := run.newApiClient()
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = run.retrieveTaskStatus(ctx, client, "task-id", true)
+
+	assert.Equal(t, QualityGateWaitTimeout, err)
+}
+
+func TestRetrieveProjectAnalysisStatusRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	run := &Run{
+		sonarHostUrl: server.URL,
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	client, err := run.newApiClient()
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = run.retrieveProjectAnalysisStatus(ctx, client, "analysis-id")
+
+	assert.Equal(t, AnalysisStatusWaitTimeout, err)
+}
+
+func TestCheckProjectAnalysisStatusOfUnfinishedTask(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`{"task": {"status": "IN_PROGRESS"}}`))
+		requests++
+	}))
+	defer server.Close()
+
+	run := &Run{
+		sonarHostUrl: server.URL,
+		reportTask:   &ReportTask{CeTaskId: "task-id"},
+		pollStrategy: &FixedPollStrategy{Interval: time.Millisecond},
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	status, err := run.CheckProjectAnalysisStatus(context.Background())
+
+	assert.Equal(t, AnalysisTaskNotFinished, err)
+	assert.Equal(t, TaskStatusUndefined, status.TaskStatus)
+	assert.Equal(t, 1, requests)
+}
+
+func TestServeProxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("sonarqube"))
+	}))
+	defer server.Close()
+
+	// Pick a free port, so that the proxy address is known upfront.
+	listener, err := net.Listen("tcp", "localhost:0")
+	assert.Nil(t, err)
+	proxyAddr := listener.Addr().String()
+	listener.Close()
+
+	run := &Run{
+		sonarHostUrl:    server.URL,
+		proxyListenAddr: proxyAddr,
+		proxyToken:      "proxy-token",
+		log:             logrus.NewEntry(logrus.New()),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- run.ServeProxy(ctx)
+	}()
+
+	var body []byte
+	assert.Eventually(t, func() bool {
+		request, _ := http.NewRequest("GET", fmt.Sprintf("http://%s/", proxyAddr), nil)
+		request.SetBasicAuth("proxy-token", "")
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return false
+		}
+		defer response.Body.Close()
+
+		body, err = ioutil.ReadAll(response.Body)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "sonarqube", string(body))
+
+	cancel()
+	assert.Nil(t, <-done)
+}
+
+func TestRetrieveProjectAnalysisStatusOfFailedTask(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.Write([]byte(`
+        {
+            "task": {
+                "id": "task-id",
+                "status": "FAILED",
+                "submittedAt": "2020-12-24T10:00:00+0000",
+                "executionTimeMs": 1500,
+                "errorMessage": "Fail to extract report",
+                "errorStacktrace": "java.lang.IllegalStateException",
+                "warnings": ["Missing blame information"]
+            }
+        }
+        `))
+	}))
+	defer server.Close()
+
+	run := &Run{
+		sonarHostUrl: server.URL,
+		reportTask:   &ReportTask{CeTaskId: "task-id"},
+		pollStrategy: &FixedPollStrategy{Interval: time.Millisecond},
+		log:          logrus.NewEntry(logrus.New()),
+	}
+
+	status, err := run.RetrieveProjectanalysisStatus(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, TaskStatusFailed, status.TaskStatus)
+	assert.True(t, time.Date(2020, 12, 24, 10, 0, 0, 0, time.UTC).Equal(status.TaskDetails.SubmittedAt))
+
+	status.TaskDetails.SubmittedAt = time.Time{}
+	assert.Equal(t, TaskDetails{
+		ExecutionTime:   1500 * time.Millisecond,
+		ErrorMessage:    "Fail to extract report",
+		ErrorStacktrace: "java.lang.IllegalStateException",
+		Warnings:        []string{"Missing blame information"},
+	}, status.TaskDetails)
+	assert.Equal(t, AnalysisStatusUndefined, status.AnalysisStatus)
 }
@@ -39,6 +39,17 @@ func (status TaskStatus) String() string {
 	}
 }
 
+// isFinal reports whether the task won't change its status anymore. Undefined
+// status is considered final, since there's nothing to wait for.
+func (status TaskStatus) isFinal() bool {
+	switch status {
+	case TaskStatusPending, TaskStatusInProgress:
+		return false
+	default:
+		return true
+	}
+}
+
 func parseTaskStatus(status string) (TaskStatus, error) {
 	switch status {
 	case taskStatusPendingStr:
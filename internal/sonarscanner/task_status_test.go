@@ -36,6 +36,15 @@ func TestParseTaskStatusInvalidInput(t *testing.T) {
 	assert.Equal(t, status, TaskStatusUndefined)
 }
 
+func TestTaskStatusIsFinal(t *testing.T) {
+	assert.False(t, TaskStatusPending.isFinal())
+	assert.False(t, TaskStatusInProgress.isFinal())
+	assert.True(t, TaskStatusSuccess.isFinal())
+	assert.True(t, TaskStatusFailed.isFinal())
+	assert.True(t, TaskStatusCancelled.isFinal())
+	assert.True(t, TaskStatusUndefined.isFinal())
+}
+
 func assertTaskStatusParsedAs(t *testing.T, statusString string, expectedStatus TaskStatus) {
 	status, err := parseTaskStatus(statusString)
 